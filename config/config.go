@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -19,6 +20,16 @@ type (
 		SMS_TOKEN        `yaml:"sms_token"`
 		Minio  `yaml:"minio"`
 		Google 	`yaml:"google"`
+		LLM    `yaml:"llm"`
+		Prompt `yaml:"prompt"`
+		Sonar  `yaml:"sonar"`
+		Coords `yaml:"coords"`
+		Directory `yaml:"directory"`
+		Verification `yaml:"verification"`
+		Embedding `yaml:"embedding"`
+		KB        `yaml:"kb"`
+		Memory    `yaml:"memory"`
+		WS        `yaml:"ws"`
 		// OpenAI `yaml:"openai"`
 	}
 
@@ -31,6 +42,8 @@ type (
 	// HTTP -.
 	HTTP struct {
 		Port string `env-required:"true" yaml:"port" env:"HTTP_PORT"`
+		// AllowedOrigins is shared by CORS and the WebSocket handshake.
+		AllowedOrigins []string `yaml:"allowed_origins" env:"HTTP_ALLOWED_ORIGINS" env-separator:","`
 	}
 
 	// Log -.
@@ -59,6 +72,82 @@ type (
 		Key string `env-required:"true" yaml:"key" env:"PERPLEXITY_API_KEY"`
 	}
 
+	// Sonar -.
+	Sonar struct {
+		BaseURL string `yaml:"base_url" env:"SONAR_BASE_URL" env-default:"https://api.perplexity.ai"`
+		Model   string `yaml:"model"    env:"SONAR_MODEL"    env-default:"sonar"`
+
+		Timeout time.Duration `yaml:"timeout" env:"SONAR_TIMEOUT" env-default:"90s"`
+		Retries int           `yaml:"retries" env:"SONAR_RETRIES" env-default:"2"`
+	}
+
+	// Coords -.
+	Coords struct {
+		Timeout     time.Duration `yaml:"timeout"      env:"COORDS_TIMEOUT"      env-default:"10s"`
+		SeleniumURL string        `yaml:"selenium_url" env:"COORDS_SELENIUM_URL"`
+		CacheTTL    time.Duration `yaml:"cache_ttl"    env:"COORDS_CACHE_TTL"    env-default:"720h"`
+		NegativeTTL time.Duration `yaml:"negative_ttl" env:"COORDS_NEGATIVE_TTL" env-default:"1h"`
+	}
+
+	// Directory -.
+	Directory struct {
+		MaxAge        time.Duration `yaml:"max_age"        env:"DIRECTORY_MAX_AGE"        env-default:"720h"`
+		MinConfidence float64       `yaml:"min_confidence" env:"DIRECTORY_MIN_CONFIDENCE" env-default:"0.9"`
+	}
+
+	// Verification -.
+	Verification struct {
+		Enabled   bool          `yaml:"enabled"    env:"VERIFICATION_ENABLED"    env-default:"true"`
+		Interval  time.Duration `yaml:"interval"   env:"VERIFICATION_INTERVAL"   env-default:"1h"`
+		MaxAge    time.Duration `yaml:"max_age"    env:"VERIFICATION_MAX_AGE"    env-default:"720h"`
+		BatchSize int           `yaml:"batch_size" env:"VERIFICATION_BATCH_SIZE" env-default:"20"`
+	}
+
+	// Embedding -.
+	Embedding struct {
+		Provider     string        `yaml:"provider"      env:"EMBEDDING_PROVIDER"      env-default:"gemini"`
+		Model        string        `yaml:"model"         env:"EMBEDDING_MODEL"`
+		BaseURL      string        `yaml:"base_url"      env:"EMBEDDING_BASE_URL"`
+		APIKey       string        `yaml:"api_key"       env:"EMBEDDING_API_KEY"`
+		Dimensions   int           `yaml:"dimensions"    env:"EMBEDDING_DIMENSIONS"    env-default:"256"`
+		Timeout      time.Duration `yaml:"timeout"       env:"EMBEDDING_TIMEOUT"       env-default:"30s"`
+		SyncInterval time.Duration `yaml:"sync_interval" env:"EMBEDDING_SYNC_INTERVAL" env-default:"1m"`
+		TopK         int           `yaml:"top_k"         env:"EMBEDDING_TOP_K"         env-default:"3"`
+		MinScore     float32       `yaml:"min_score"     env:"EMBEDDING_MIN_SCORE"     env-default:"0.5"`
+		ReuseScore   float32       `yaml:"reuse_score"   env:"EMBEDDING_REUSE_SCORE"   env-default:"0.95"`
+		ReuseMaxAge  time.Duration `yaml:"reuse_max_age" env:"EMBEDDING_REUSE_MAX_AGE" env-default:"168h"`
+	}
+
+	// Memory -.
+	Memory struct {
+		MaxExchanges int           `yaml:"max_exchanges" env:"MEMORY_MAX_EXCHANGES" env-default:"10"`
+		TokenBudget  int           `yaml:"token_budget"  env:"MEMORY_TOKEN_BUDGET"  env-default:"1500"`
+		CacheTTL     time.Duration `yaml:"cache_ttl"     env:"MEMORY_CACHE_TTL"     env-default:"24h"`
+
+		SummaryEvery    int           `yaml:"summary_every"    env:"MEMORY_SUMMARY_EVERY"    env-default:"5"`
+		SummaryInterval time.Duration `yaml:"summary_interval" env:"MEMORY_SUMMARY_INTERVAL" env-default:"1m"`
+		SummaryBatch    int           `yaml:"summary_batch"    env:"MEMORY_SUMMARY_BATCH"    env-default:"20"`
+	}
+
+	// WS -.
+	WS struct {
+		PingInterval   time.Duration `yaml:"ping_interval"    env:"WS_PING_INTERVAL"    env-default:"30s"`
+		PongWait       time.Duration `yaml:"pong_wait"        env:"WS_PONG_WAIT"        env-default:"60s"`
+		WriteWait      time.Duration `yaml:"write_wait"       env:"WS_WRITE_WAIT"       env-default:"10s"`
+		IdleTimeout    time.Duration `yaml:"idle_timeout"     env:"WS_IDLE_TIMEOUT"     env-default:"10m"`
+		MaxMessageSize int64         `yaml:"max_message_size" env:"WS_MAX_MESSAGE_SIZE" env-default:"16384"`
+		ResumeWindow   time.Duration `yaml:"resume_window"    env:"WS_RESUME_WINDOW"    env-default:"5m"`
+	}
+
+	// KB -.
+	KB struct {
+		MaxFileSize  int64   `yaml:"max_file_size" env:"KB_MAX_FILE_SIZE" env-default:"20971520"`
+		ChunkSize    int     `yaml:"chunk_size"    env:"KB_CHUNK_SIZE"    env-default:"1000"`
+		ChunkOverlap int     `yaml:"chunk_overlap" env:"KB_CHUNK_OVERLAP" env-default:"150"`
+		TopK         int     `yaml:"top_k"         env:"KB_TOP_K"         env-default:"3"`
+		MinScore     float32 `yaml:"min_score"     env:"KB_MIN_SCORE"     env-default:"0.6"`
+	}
+
 	// SMS_TOKEN -.
 	SMS_TOKEN struct {
 		Token string `env-required:"true" yaml:"token" env:"SMS_TOKEN"`
@@ -69,7 +158,27 @@ type (
 		ClientID     string `env-required:"true" yaml:"client_id" env:"GOOGLE_CLIENT_ID"`
 	}
 
-	
+
+	// LLM -.
+	LLM struct {
+		Provider string `yaml:"provider" env:"LLM_PROVIDER" env-default:"gemini"`
+		Model    string `yaml:"model"    env:"LLM_MODEL"`
+		BaseURL  string `yaml:"base_url" env:"LLM_BASE_URL"`
+		APIKey   string `env:"LLM_API_KEY"`
+
+		PoolSize         int           `yaml:"pool_size"         env:"LLM_POOL_SIZE"         env-default:"4"`
+		Timeout          time.Duration `yaml:"timeout"           env:"LLM_TIMEOUT"           env-default:"30s"`
+		BreakerThreshold int           `yaml:"breaker_threshold" env:"LLM_BREAKER_THRESHOLD" env-default:"5"`
+		BreakerCooldown  time.Duration `yaml:"breaker_cooldown"  env:"LLM_BREAKER_COOLDOWN"  env-default:"30s"`
+		Retries          int           `yaml:"retries"           env:"LLM_RETRIES"           env-default:"2"`
+	}
+
+	// Prompt -.
+	Prompt struct {
+		Dir            string        `yaml:"dir"             env:"PROMPT_DIR"             env-default:"./prompts"`
+		ReloadInterval time.Duration `yaml:"reload_interval" env:"PROMPT_RELOAD_INTERVAL" env-default:"30s"`
+	}
+
 	// Minio -.
 	Minio struct {
 		MINIO_ENDPOINT    string `env-required:"true" yaml:"MINIO_ENDPOINT" env:"MINIO_ENDPOINT"`
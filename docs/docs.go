@@ -259,14 +259,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/restrictions/get": {
-            "get": {
+        "/kb/documents/delete": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get restriction by ID",
+                "description": "Delete a document; answers stop citing it after the next index sync",
                 "consumes": [
                     "application/json"
                 ],
@@ -274,13 +274,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Restrictions"
+                    "Knowledge base"
                 ],
-                "summary": "Get restriction by ID",
+                "summary": "Delete a knowledge base document",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Restriction ID",
+                        "description": "Document ID",
                         "name": "id",
                         "in": "query",
                         "required": true
@@ -290,11 +290,11 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.Restriction"
+                            "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "400": {
+                        "description": "Invalid request",
                         "schema": {
                             "type": "string"
                         }
@@ -302,14 +302,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/restrictions/list": {
+        "/kb/documents/list": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get list of restrictions",
+                "description": "Get the uploaded documents, newest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -317,10 +317,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Restrictions"
+                    "Knowledge base"
                 ],
-                "summary": "Get all restrictions",
+                "summary": "Get knowledge base documents",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Limit",
@@ -338,10 +344,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/entity.Restriction"
-                            }
+                            "$ref": "#/definitions/entity.KBDocumentList"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "500": {
@@ -353,51 +362,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/restrictions/update": {
-            "put": {
+        "/kb/documents/upload": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update an existing restriction",
+                "description": "Upload an official document (.txt, .md, .pdf or .docx). Its text is chunked and indexed, and chat answers cite it ahead of web results.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Restrictions"
+                    "Knowledge base"
                 ],
-                "summary": "Update a restriction",
+                "summary": "Upload a knowledge base document",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Restriction ID",
-                        "name": "id",
-                        "in": "query",
+                        "type": "file",
+                        "description": "Document",
+                        "name": "file",
+                        "in": "formData",
                         "required": true
                     },
                     {
-                        "description": "Update data",
-                        "name": "restriction",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entity.UpdateRestrictionBody"
-                        }
+                        "type": "string",
+                        "description": "Title (default: file name)",
+                        "name": "title",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated tags",
+                        "name": "tags",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Restriction updated successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.KBDocument"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid request",
                         "schema": {
                             "type": "string"
                         }
@@ -411,14 +423,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/delete": {
-            "delete": {
+        "/organizations": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a User by ID",
+                "description": "Search the organization directory by text, industry, city and distance. Results use the same shape as the organizations streamed over the WebSocket.",
                 "consumes": [
                     "application/json"
                 ],
@@ -426,103 +438,83 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Organizations"
                 ],
-                "summary": "Delete a User",
+                "summary": "Search organizations",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "User deleted successfully",
-                        "schema": {
-                            "type": "string"
-                        }
+                        "description": "Full-text query over name and description",
+                        "name": "q",
+                        "in": "query"
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "string"
-                        }
+                    {
+                        "type": "string",
+                        "description": "Industry",
+                        "name": "industry",
+                        "in": "query"
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
-            }
-        },
-        "/users/google/login": {
-            "post": {
-                "description": "Login or register user using Google ID Token",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Users"
-                ],
-                "summary": "Login with Google",
-                "parameters": [
                     {
-                        "description": "Google ID Token",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entity.GoogleLoginReq"
-                        }
+                        "type": "string",
+                        "description": "City",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Center point as lat,lng",
+                        "name": "near",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Radius around near in meters (default 5000, max 50000)",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit (default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/entity.OrgInfoList"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "type": "string"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/users/list": {
+        "/organizations/merges": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all Users with optional filtering",
+                "description": "Get possible duplicate organizations found by the matcher, best matches first",
                 "consumes": [
                     "application/json"
                 ],
@@ -530,10 +522,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Organizations"
                 ],
-                "summary": "Get all Users",
+                "summary": "Get organization merge candidates",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, confirmed, rejected or obsolete",
+                        "name": "status",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Limit",
@@ -545,29 +543,23 @@ const docTemplate = `{
                         "description": "Offset",
                         "name": "offset",
                         "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "User Status",
-                        "name": "status",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.UserList"
+                            "$ref": "#/definitions/entity.MergeCandidateList"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid request",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
                             "type": "string"
                         }
@@ -575,14 +567,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/login": {
-            "post": {
+        "/organizations/merges/confirm": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "User login with phone number and password",
+                "description": "Merge the duplicate into the organization and remove the duplicate from the directory",
                 "consumes": [
                     "application/json"
                 ],
@@ -590,35 +582,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Organizations"
                 ],
-                "summary": "User login",
+                "summary": "Confirm an organization merge",
                 "parameters": [
                     {
-                        "description": "User Login Details",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entity.LoginReq"
-                        }
+                        "type": "string",
+                        "description": "Merge candidate ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/entity.LoginRes"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Invalid request",
                         "schema": {
                             "type": "string"
                         }
@@ -626,31 +610,57 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/logout": {
-            "post": {
-                "description": "Clear user cookie",
+        "/organizations/merges/reject": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mark the candidate as two different organizations",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Users"
+                    "Organizations"
+                ],
+                "summary": "Reject an organization merge",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merge candidate ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
-                "summary": "User logout",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/users/me": {
+        "/organizations/revisions": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get information about the currently authenticated user",
+                "description": "Get the facts that changed when organizations were re-verified, newest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -658,24 +668,692 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Organizations"
+                ],
+                "summary": "Get organization revisions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Organization ID",
+                        "name": "organization_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get current user info",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.GetMe"
+                            "$ref": "#/definitions/entity.OrganizationRevisionList"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid request",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/prompts/activate": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Make the given version the active one. The body is test-rendered with the data the prompt gets first. Version 0 falls back to the template file.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Prompts"
+                ],
+                "summary": "Activate a prompt version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Prompt name",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Prompt version",
+                        "name": "version",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Prompt version not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/prompts/create": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Store a new version of a prompt. The body is test-rendered with the data the prompt gets. The version is not active until it is activated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Prompts"
+                ],
+                "summary": "Create a prompt version",
+                "parameters": [
+                    {
+                        "description": "Prompt data",
+                        "name": "prompt",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.CreatePrompt"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Prompt"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/prompts/list": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get stored prompt versions, optionally filtered by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Prompts"
+                ],
+                "summary": "Get all prompt versions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Prompt name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.PromptList"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/prompts/preview": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Render a prompt with the given data, or with sample data when none is given. Uses body if given, otherwise the stored version, otherwise the active one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Prompts"
+                ],
+                "summary": "Preview a prompt",
+                "parameters": [
+                    {
+                        "description": "Preview data",
+                        "name": "prompt",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.PreviewPrompt"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.PreviewPromptRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Prompt version not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/restrictions/get": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get restriction by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Restrictions"
+                ],
+                "summary": "Get restriction by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Restriction ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Restriction"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/restrictions/list": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get list of restrictions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Restrictions"
+                ],
+                "summary": "Get all restrictions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Restriction"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/restrictions/update": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing restriction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Restrictions"
+                ],
+                "summary": "Update a restriction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Restriction ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Update data",
+                        "name": "restriction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.UpdateRestrictionBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Restriction updated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/delete": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a User by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete a User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/google/login": {
+            "post": {
+                "description": "Login or register user using Google ID Token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Login with Google",
+                "parameters": [
+                    {
+                        "description": "Google ID Token",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.GoogleLoginReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/list": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all Users with optional filtering",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get all Users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User Status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.UserList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/login": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "User login with phone number and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "description": "User Login Details",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.LoginReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.LoginRes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/logout": {
+            "post": {
+                "description": "Clear user cookie",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "User logout",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get information about the currently authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get current user info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.GetMe"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "string"
                         }
@@ -844,67 +1522,345 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "entity.ChatList": {
+        },
+        "/ws/{chat_room_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upgrades to a WebSocket for the chat room. The caller must own the room and send the access_token cookie or a bearer token. Offer the \"chatbot.v2\" subprotocol to get every event in a typed envelope; clients that offer none get the version 1 frames. Events and payloads are described in docs/websocket.md.",
+                "tags": [
+                    "Chat"
+                ],
+                "summary": "Chat over WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Chat room ID",
+                        "name": "chat_room_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "chatbot.v2 or chatbot.v1",
+                        "name": "Sec-WebSocket-Protocol",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "\u003cmessage_id\u003e:\u003cseq\u003e of the last event received before a disconnect",
+                        "name": "resume_from",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/entity.WSEnvelope"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "entity.ChatList": {
+            "type": "object",
+            "properties": {
+                "chats": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.ChatResponce"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "has_more": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "entity.ChatResponce": {
+            "type": "object",
+            "properties": {
+                "chat_room_id": {
+                    "type": "string"
+                },
+                "content": {
+                    "$ref": "#/definitions/entity.ContentRes"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ChatRoom": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.ChatRoomList": {
+            "type": "object",
+            "properties": {
+                "chat_rooms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.ChatRoom"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ContentRes": {
+            "type": "object",
+            "properties": {
+                "citations": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.DocumentCitation"
+                    }
+                },
+                "images_url": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "interrupted": {
+                    "type": "boolean"
+                },
+                "location": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "type": "number",
+                            "format": "float64"
+                        }
+                    }
+                },
+                "organizations": {},
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.CreatePrompt": {
             "type": "object",
+            "required": [
+                "body",
+                "name"
+            ],
             "properties": {
-                "chats": {
+                "body": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.DocumentCitation": {
+            "type": "object",
+            "properties": {
+                "document_id": {
+                    "type": "string"
+                },
+                "excerpt": {
+                    "type": "string"
+                },
+                "tags": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entity.ChatResponce"
+                        "type": "string"
                     }
                 },
-                "count": {
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.GetMe": {
+            "type": "object",
+            "properties": {
+                "avatar": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "full_name": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "limit": {
                     "type": "integer"
                 },
-                "has_more": {
-                    "type": "boolean"
+                "role": {
+                    "type": "string"
                 }
             }
         },
-        "entity.ChatResponce": {
+        "entity.GoogleLoginReq": {
             "type": "object",
+            "required": [
+                "id_token"
+            ],
             "properties": {
-                "chat_room_id": {
+                "id_token": {
                     "type": "string"
+                }
+            }
+        },
+        "entity.KBDocument": {
+            "type": "object",
+            "properties": {
+                "chunk_count": {
+                    "type": "integer"
                 },
-                "content": {
-                    "$ref": "#/definitions/entity.ContentRes"
+                "content_type": {
+                    "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
+                "file_name": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
-                "role": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uploaded_by": {
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
                 }
             }
         },
-        "entity.ChatRoom": {
+        "entity.KBDocumentList": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.KBDocument"
+                    }
+                }
+            }
+        },
+        "entity.LoginReq": {
+            "type": "object",
+            "properties": {
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.LoginRes": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.MergeCandidate": {
             "type": "object",
             "properties": {
+                "confidence": {
+                    "type": "number"
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "decided_at": {
+                    "type": "string"
+                },
+                "decided_by": {
+                    "type": "string"
+                },
+                "duplicate": {
+                    "$ref": "#/definitions/entity.OrganizationRef"
+                },
                 "id": {
                     "type": "string"
                 },
-                "title": {
+                "organization": {
+                    "$ref": "#/definitions/entity.OrganizationRef"
+                },
+                "reasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "entity.ChatRoomList": {
+        "entity.MergeCandidateList": {
             "type": "object",
             "properties": {
-                "chat_rooms": {
+                "candidates": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entity.ChatRoom"
+                        "$ref": "#/definitions/entity.MergeCandidate"
                     }
                 },
                 "count": {
@@ -912,87 +1868,189 @@ const docTemplate = `{
                 }
             }
         },
-        "entity.ContentRes": {
+        "entity.OrgInfo": {
             "type": "object",
             "properties": {
-                "citations": {
+                "address": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "images_url": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "images_url": {
+                "location": {
+                    "type": "object",
+                    "properties": {
+                        "latitude": {
+                            "type": "number"
+                        },
+                        "longitude": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "sources": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "location": {
+                "website": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.OrgInfoList": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "organizations": {
                     "type": "array",
                     "items": {
-                        "type": "object",
-                        "additionalProperties": {
-                            "type": "number",
-                            "format": "float64"
-                        }
+                        "$ref": "#/definitions/entity.OrgInfo"
                     }
+                }
+            }
+        },
+        "entity.OrganizationRef": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
                 },
-                "organizations": {},
-                "text": {
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "entity.GetMe": {
+        "entity.OrganizationRevision": {
             "type": "object",
             "properties": {
-                "avatar": {
+                "created_at": {
                     "type": "string"
                 },
-                "email": {
+                "field": {
                     "type": "string"
                 },
-                "full_name": {
+                "id": {
                     "type": "string"
                 },
-                "language": {
+                "new_value": {
                     "type": "string"
                 },
-                "limit": {
-                    "type": "integer"
+                "observed_at": {
+                    "type": "string"
                 },
-                "role": {
+                "old_value": {
+                    "type": "string"
+                },
+                "organization": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "string"
+                },
+                "source_url": {
                     "type": "string"
                 }
             }
         },
-        "entity.GoogleLoginReq": {
+        "entity.OrganizationRevisionList": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "revisions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.OrganizationRevision"
+                    }
+                }
+            }
+        },
+        "entity.PreviewPrompt": {
             "type": "object",
             "required": [
-                "id_token"
+                "name"
             ],
             "properties": {
-                "id_token": {
+                "body": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "name": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "entity.LoginReq": {
+        "entity.PreviewPromptRes": {
             "type": "object",
             "properties": {
-                "phone_number": {
+                "text": {
                     "type": "string"
                 }
             }
         },
-        "entity.LoginRes": {
+        "entity.Prompt": {
             "type": "object",
             "properties": {
-                "message": {
+                "body": {
                     "type": "string"
                 },
-                "token": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "name": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.PromptList": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "prompts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Prompt"
+                    }
                 }
             }
         },
@@ -1100,6 +2158,32 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "entity.WSEnvelope": {
+            "type": "object",
+            "properties": {
+                "message_id": {
+                    "type": "string"
+                },
+                "payload": {},
+                "seq": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "delta",
+                        "content",
+                        "organization",
+                        "citation",
+                        "location",
+                        "warning",
+                        "error",
+                        "done",
+                        "cancelled"
+                    ]
+                }
+            }
         }
     },
     "securityDefinitions": {
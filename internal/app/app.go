@@ -5,23 +5,29 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/generative-ai-go/genai"
 	"github.com/redis/go-redis/v9"
 	"github.com/tebeka/selenium"
 	"github.com/tebeka/selenium/chrome"
-	"google.golang.org/api/option"
 
 	"chatbot/config"
 	v1 "chatbot/internal/controller/http"
 	"chatbot/internal/usecase"
 
+	"chatbot/pkg/coords"
+	"chatbot/pkg/embedding"
+	"chatbot/pkg/gemini"
+	"chatbot/pkg/httpclient"
 	"chatbot/pkg/httpserver"
+	"chatbot/pkg/memory"
 	"chatbot/pkg/minio"
 	"chatbot/pkg/postgres"
+	"chatbot/pkg/prompt"
+	"chatbot/pkg/sonar"
 )
 
 func Run(cfg *config.Config) {
@@ -54,6 +60,31 @@ func Run(cfg *config.Config) {
 	}
 	defer pg.Close()
 
+	// redis
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     "redis:6379",
+		Password: "4545",
+		DB:       0,
+	})
+	defer rdb.Close()
+
+	// Background loops run until a signal cancels ctx. They are stopped
+	// before Postgres and Redis close.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	var wg sync.WaitGroup
+	background := func(run func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run()
+		}()
+	}
+	shutdown := func() {
+		stop()
+		wg.Wait()
+	}
+	defer shutdown()
+
 	// Use case
 	useCase := usecase.New(pg, cfg)
 
@@ -67,20 +98,64 @@ func Run(cfg *config.Config) {
 	}
 	caps.AddChrome(chromeCaps)
 
+	// Prompts
+	prompts := prompt.New(cfg.Prompt.Dir, useCase.PromptRepo)
+	if err := prompts.Load(ctx); err != nil {
+		slog.Error("failed to load prompts", "error", err)
+		return
+	}
+	background(func() { prompts.Watch(ctx, cfg.Prompt.ReloadInterval) })
+
 	// Gemini
-	ctx := context.Background()
-	gemini_client, err := genai.NewClient(ctx, option.WithAPIKey(cfg.ApiKey.Key))
+	llm, err := gemini.New(ctx, cfg, prompts)
 	if err != nil {
-		slog.Error("failed to create Gemini client", "error", err)
+		slog.Error("failed to create LLM service", "error", err)
+		return
 	}
-	defer gemini_client.Close()
+	defer func() {
+		// The summarizer calls the model until it stops.
+		shutdown()
+		llm.Close()
+	}()
+
+	// Sonar
+	searchClient := httpclient.New(
+		httpclient.Timeout(cfg.Sonar.Timeout),
+		httpclient.Retries(cfg.Sonar.Retries),
+	)
+	search := sonar.NewPerplexity(cfg.Sonar.BaseURL, cfg.PerplexityAPIKey.Key, cfg.Sonar.Model, searchClient)
+
+	// Conversation memory: saved exchanges go through it into the cache.
+	mem := memory.New(useCase.ChatRepo, rdb, cfg)
+	useCase.ChatRepo = mem
+	summarizer := memory.NewSummarizer(mem, llm, cfg.Memory.SummaryEvery, cfg.Memory.SummaryBatch)
+	background(func() { summarizer.Run(ctx, cfg.Memory.SummaryInterval) })
+
+	// Coordinates
+	resolver := coords.NewResolver(
+		httpclient.New(httpclient.Timeout(cfg.Coords.Timeout)),
+		coords.SeleniumFallback(cfg.Coords.SeleniumURL),
+		coords.Cache(rdb, useCase.GeoCacheRepo, cfg.Coords.CacheTTL, cfg.Coords.NegativeTTL),
+	)
+
+	// Semantic index
+	embedder, err := embedding.New(cfg)
+	if err != nil {
+		slog.Error("failed to create embedder", "error", err)
+		return
+	}
+	retriever := embedding.NewRetriever(embedder, useCase.ChatRepo.GetAnswersSince, useCase.OrganizationRepo.GetSnippetsSince, useCase.KBRepo.GetSnippetsSince)
+	if err := retriever.Sync(ctx); err != nil {
+		// Answers still work without grounding, the next sync retries.
+		slog.Error("failed to build semantic index", "error", err)
+	}
+	background(func() { retriever.Watch(ctx, cfg.Embedding.SyncInterval) })
 
-	// redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     "redis:6379",
-		Password: "4545",
-		DB:       0,
-	})
+	// Organization re-verification
+	if cfg.Verification.Enabled {
+		verifier := sonar.NewVerifier(search, prompts, useCase.OrganizationRepo, cfg.Verification.MaxAge, cfg.Verification.BatchSize)
+		background(func() { verifier.Run(ctx, cfg.Verification.Interval) })
+	}
 
 	//MinIO
 	minioClient, err := minio.MinIOConnect(cfg)
@@ -92,19 +167,16 @@ func Run(cfg *config.Config) {
 
 	// HTTP Server
 	handler := gin.New()
-	v1.NewRouter(handler, cfg, useCase, gemini_client, rdb, minioClient)
+	v1.NewRouter(handler, cfg, useCase, llm, rdb, minioClient, prompts, search, resolver, retriever, mem)
 
 	httpServer := httpserver.New(handler, httpserver.Port(cfg.HTTP.Port))
 
 	slog.Info("app - Run - httpServer: %s", cfg.HTTP.Port)
 
 	// Waiting signal
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
-
 	select {
-	case s := <-interrupt:
-		slog.Info("app - Run - signal: %s", s.String())
+	case <-ctx.Done():
+		slog.Info("app - Run - signal received")
 	case err = <-httpServer.Notify():
 		slog.Error("app - Run - httpServer.Notify:", err)
 	}
@@ -1,28 +1,62 @@
 package handler
 
 import (
+	middleware "chatbot/internal/controller/http/middlerware"
+	"chatbot/internal/controller/http/token"
 	"chatbot/internal/entity"
 	"chatbot/pkg/cache"
+	"chatbot/pkg/directory"
+	"chatbot/pkg/embedding"
 	"chatbot/pkg/gemini"
+	"chatbot/pkg/httpclient"
+	"chatbot/pkg/lang"
+	"chatbot/pkg/prompt"
 	"chatbot/pkg/sonar"
+	"chatbot/pkg/wsproto"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v4"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool { return true },
-}
+// Close codes for rejected handshakes, in the range left to applications.
+// Browsers cannot read the status of a refused upgrade, so the socket is
+// accepted and closed with one of these instead.
+const (
+	closeBadRequest   = 4400
+	closeUnauthorized = 4401
+	closeForbidden    = 4403
+)
 
+// ChatWS godoc
+// @Summary Chat over WebSocket
+// @Description Upgrades to a WebSocket for the chat room. The caller must own the room and send the access_token cookie or a bearer token. Offer the "chatbot.v2" subprotocol to get every event in a typed envelope; clients that offer none get the version 1 frames. Events and payloads are described in docs/websocket.md.
+// @Tags Chat
+// @Param chat_room_id path string true "Chat room ID"
+// @Param Sec-WebSocket-Protocol header string false "chatbot.v2 or chatbot.v1"
+// @Param resume_from query string false "<message_id>:<seq> of the last event received before a disconnect"
+// @Success 101 {object} entity.WSEnvelope
+// @Security BearerAuth
+// @Router /ws/{chat_room_id} [get]
 func (h *Handler) ChatWS(c *gin.Context) {
 	chatRoomID := c.Param("chat_room_id")
 	ctx := context.Background()
 
+	upgrader := websocket.Upgrader{
+		CheckOrigin:  h.checkOrigin,
+		Subprotocols: wsproto.Subprotocols,
+	}
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		slog.Error("WebSocket upgrade error", "error", err)
@@ -30,10 +64,71 @@ func (h *Handler) ChatWS(c *gin.Context) {
 	}
 	defer conn.Close()
 
+	userID, code, err := h.authorizeChatRoom(ctx, c.Request, chatRoomID)
+	if err != nil {
+		slog.Warn("WebSocket rejected", "chat_room_id", chatRoomID, "code", code, "error", err)
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, err.Error()), time.Now().Add(time.Second))
+		return
+	}
+
+	buffer := wsproto.NewBuffer(h.Redis, h.Config.WS.ResumeWindow)
+	out := wsproto.New(conn, h.Config.WS, buffer, chatRoomID)
+	defer out.Close()
+
+	storedLang := ""
+	if me, err := h.UseCase.UserRepo.GetMe(ctx, userID); err == nil {
+		storedLang = me.Language
+	}
+
+	// Answers run next to the read loop, so a cancel or a rephrased question
+	// reaches them mid-stream. One answer runs at a time.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var inflight *inflightAnswer
+	stop := func() {
+		if inflight != nil {
+			inflight.cancel()
+			<-inflight.done
+			inflight = nil
+		}
+	}
+	start := func(resumable bool, job func(ctx context.Context)) {
+		jobCtx, cancelJob := context.WithCancel(ctx)
+		inflight = &inflightAnswer{cancel: cancelJob, done: make(chan struct{}), resumable: resumable}
+		out.Busy()
+
+		go func(done chan struct{}) {
+			defer close(done)
+			defer cancelJob()
+			defer out.Idle()
+			job(jobCtx)
+		}(inflight.done)
+	}
+	defer func() {
+		// A client that dropped can come back for a buffered answer, so it
+		// is finished rather than cancelled.
+		if inflight != nil && inflight.resumable {
+			<-inflight.done
+		}
+		stop()
+	}()
+
+	if resumeFrom := c.Query("resume_from"); resumeFrom != "" {
+		messageID, seq, err := parseResumeFrom(resumeFrom)
+		if err != nil {
+			slog.Warn("Invalid resume_from", "chat_room_id", chatRoomID, "resume_from", resumeFrom, "error", err)
+		} else {
+			start(false, func(ctx context.Context) {
+				h.resume(ctx, out, chatRoomID, storedLang, messageID, seq)
+			})
+		}
+	}
+
 	for {
-		_, msg, err := conn.ReadMessage()
+		msg, err := out.Read()
 		if err != nil {
-			slog.Error("Read message error", "error", err)
+			slog.Info("WebSocket closed", "chat_room_id", chatRoomID, "error", err)
 			break
 		}
 		var req entity.Request
@@ -41,111 +136,396 @@ func (h *Handler) ChatWS(c *gin.Context) {
 			slog.Error("JSON parse error", "error", err)
 			continue
 		}
-		request := req.Message
 
-		_, err = h.UseCase.ChatRepo.Check(ctx, "", chatRoomID)
-		if err != nil {
-			if err.Error() == "kunlik limiti tugadi" || err.Error() == "sizning 3 ta bepul so‘rovingiz tugadi, davom etish uchun ro‘yxatdan o‘ting" {
-				_ = conn.WriteJSON(map[string]any{
-					"type":  "warning",
-					"error": err.Error(),
-				})
-				continue
+		stop()
+		if req.Type == entity.RequestCancel {
+			out.Idle()
+			continue
+		}
+
+		answer := out.Message()
+		start(out.Resumable(), func(ctx context.Context) {
+			err := h.answer(ctx, answer, chatRoomID, userID, storedLang, req.Message)
+			switch {
+			case errors.Is(err, context.Canceled):
+				if err := answer.Cancelled(); err != nil {
+					slog.Warn("Failed to send cancelled event", "error", err)
+				}
+			case err != nil:
+				slog.Error("Answer failed", "chat_room_id", chatRoomID, "error", err)
+				out.Close()
 			}
-			slog.Error("Check error", "error", err)
-			break
+		})
+	}
+}
+
+// inflightAnswer is the answer a connection is streaming, or the one it
+// resumes.
+type inflightAnswer struct {
+	cancel    context.CancelFunc
+	done      chan struct{}
+	resumable bool
+}
+
+// parseResumeFrom parses "<message_id>:<seq>".
+func parseResumeFrom(s string) (string, int, error) {
+	messageID, seqStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, errors.New("want <message_id>:<seq>")
+	}
+	if _, err := uuid.Parse(messageID); err != nil {
+		return "", 0, fmt.Errorf("invalid message_id: %w", err)
+	}
+	seq, err := strconv.Atoi(seqStr)
+	if err != nil || seq < 0 {
+		return "", 0, fmt.Errorf("invalid seq: %q", seqStr)
+	}
+	return messageID, seq, nil
+}
+
+// resume sends a client that reconnected what it missed of an answer: the
+// buffered events and then the live ones, or the stored answer once the
+// buffer has expired.
+func (h *Handler) resume(ctx context.Context, out *wsproto.Conn, chatRoomID, language, messageID string, seq int) {
+	answer, err := out.Resume(ctx, messageID, seq)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+
+	if errors.Is(err, wsproto.ErrNotBuffered) {
+		stored, err := h.UseCase.ChatRepo.GetAnswer(ctx, chatRoomID, messageID)
+		if err == nil {
+			if err := writeStoredAnswer(answer, stored); err != nil {
+				slog.Warn("Failed to send stored answer", "message_id", messageID, "error", err)
+			}
+			return
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			slog.Error("Failed to get stored answer", "message_id", messageID, "error", err)
 		}
+	} else {
+		slog.Warn("Failed to resume answer", "message_id", messageID, "error", err)
+	}
 
-		oldQueries, err := cache.GetUserQueries(h.Redis, ctx, chatRoomID, int64(5))
-		if err != nil {
-			err = conn.WriteJSON(map[string]any{
-						"type":  "error",
-						"message": err.Error(),
-					})
-					if err != nil {
-						slog.Warn("Failed to get old queries", "error", err)
-						break
-					}
-				return
-		}
-
-		var organizations []cache.Organization
-		organizations, err = cache.GetChatOrganizations(
-			h.Redis,
-			ctx,
-			"o"+chatRoomID,
-			5,
-		)
+	if err := answer.Error(lang.AnswerFailed, lang.T(language, lang.AnswerFailed), true); err != nil {
+		slog.Warn("Failed to send error event", "error", err)
+	}
+}
+
+// writeStoredAnswer sends a saved answer as its content, ending with
+// cancelled when it was interrupted.
+func writeStoredAnswer(answer *wsproto.Message, stored *entity.ContentRes) error {
+	var orgs []entity.OrgInfo
+	if raw, ok := stored.Organizations.(json.RawMessage); ok && len(raw) > 0 {
+		if err := json.Unmarshal(raw, &orgs); err != nil {
+			slog.Warn("Invalid stored organizations", "error", err)
+		}
+	}
+
+	var locations []entity.Location
+	for _, l := range stored.Location {
+		locations = append(locations, entity.Location{Latitude: l["latitude"], Longitude: l["longitude"]})
+	}
+
+	err := answer.Content(entity.WSContentPayload{
+		Text:          stored.Text,
+		Citations:     stored.Citations,
+		Location:      locations,
+		ImagesURL:     stored.ImagesURL,
+		Organizations: orgs,
+		Documents:     stored.Documents,
+	})
+	if err != nil {
+		return err
+	}
+
+	if stored.Interrupted {
+		return answer.Cancelled()
+	}
+	return answer.Done()
+}
+
+// answer answers one question. Errors it returns end the connection,
+// except context.Canceled, which means the client cancelled the answer.
+func (h *Handler) answer(ctx context.Context, answer *wsproto.Message, chatRoomID, userID, storedLang, request string) error {
+	language := lang.Resolve(request, storedLang)
+
+	_, err := h.UseCase.ChatRepo.Check(ctx, userID, chatRoomID, language)
+	if err != nil {
+		var limitErr *lang.Error
+		if errors.As(err, &limitErr) {
+			_ = answer.Warning(limitErr.Key, err.Error())
+			return nil
+		}
+		return fmt.Errorf("check: %w", err)
+	}
+
+	conversation, err := h.Memory.Window(ctx, chatRoomID)
+	if err != nil {
+		// The router still works on the question alone.
+		slog.Warn("Failed to load conversation history", "error", err)
+		conversation = &entity.Conversation{}
+	}
+
+	var organizations []cache.Organization
+	organizations, err = cache.GetChatOrganizations(
+		h.Redis,
+		ctx,
+		"o"+chatRoomID,
+		5,
+	)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		slog.Error("Failed to get organizations", "chat_room_id", chatRoomID, "error", err)
+		if err := writeAnswerError(answer, language, err); err != nil {
+			return fmt.Errorf("send error event: %w", err)
+		}
+		return nil
+	}
+
+	geminiResp := h.LLM.GetResponse(ctx, gemini.RouteRequest{
+		Question:      request,
+		Language:      language,
+		Organizations: organizations,
+		History:       conversation.Turns,
+		Summary:       conversation.Summary,
+		Grounding:     h.grounding(ctx, request),
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	promptVersions := map[string]int{}
+	if geminiResp == nil {
+		// The router is unavailable, answer from search with the question as is.
+		slog.Warn("Router unavailable, falling back to Sonar", "chat_room_id", chatRoomID)
+		geminiResp = &gemini.GeminiResponse{
+			Route:         gemini.RouteSonar,
+			EnrichedQuery: request,
+		}
+	} else {
+		promptVersions[prompt.Router] = geminiResp.PromptVersion
+	}
+
+	if geminiResp.Route == gemini.RouteGemini {
+		err = answer.Content(entity.WSContentPayload{
+			Text: geminiResp.Explanation,
+		})
 		if err != nil {
-			slog.Warn("Failed to get organizations", "error", err)
-			organizations = nil
+			return fmt.Errorf("write message: %w", err)
 		}
 
+		if err := answer.Done(); err != nil {
+			return fmt.Errorf("send end status: %w", err)
+		}
+		go h.SaveResponce(answer.ID(), request, chatRoomID, geminiResp.Explanation, "", promptVersions)
+		return nil
+	}
 
-		fmt.Println("Old queries:", oldQueries)
+	// Lists are left to Sonar: the directory cannot tell whether it
+	// knows all the organizations a question asks for.
+	if !geminiResp.ExpectsMultiple {
+		answered, err := h.answerFromDirectory(ctx, answer, request, geminiResp.EnrichedQuery, chatRoomID, language, promptVersions)
+		if err != nil {
+			return fmt.Errorf("write message: %w", err)
+		}
+		if answered {
+			return nil
+		}
+	}
 
-		geminiResp := gemini.GetResponse(*h.Config, request, oldQueries, organizations)
-		if geminiResp == nil {
-			_ = conn.WriteJSON(map[string]any{
-				"type":  "error",
-				"error": "Failed to get response from Gemini",
-			})
-			continue
+	answered, err := h.answerFromPreviousAnswer(ctx, answer, request, geminiResp.EnrichedQuery, chatRoomID, promptVersions)
+	if err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+	if answered {
+		return nil
+	}
+
+	documents := h.documentCitations(ctx, geminiResp.EnrichedQuery)
+	if geminiResp.ExpectsMultiple {
+		err = sonar.StreamToWS(ctx, h.Search, h.UseCase, h.Prompts, answer, request, geminiResp.EnrichedQuery, chatRoomID, language, documents, promptVersions)
+	} else {
+		err = sonar.StreamToWSOneOrg(ctx, h.Search, h.Coords, h.UseCase, h.LLM, h.Prompts, *h.Redis, answer, request, geminiResp.EnrichedQuery, chatRoomID, language, documents, promptVersions)
+	}
+	if err != nil {
+		// Sonar saves what was streamed before a cancel.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		slog.Error("Sonar error", "error", err)
+		if err := writeAnswerError(answer, language, err); err != nil {
+			return fmt.Errorf("send error event: %w", err)
 		}
+	}
 
-		go func() {
-			if err := cache.AppendUserQuery(h.Redis, ctx, chatRoomID, geminiResp.EnrichedQuery); err != nil {
-				slog.Warn("Failed to append user query", "error", err)
-			}
-		}()
-
-		if geminiResp.Route == "gemini" {
-			err = conn.WriteJSON(map[string]any{
-				"content": map[string]any{
-					"text":          geminiResp.Explanation,
-					"citations":     nil,
-					"location":      nil,
-					"images_url":    nil,
-					"organizations": nil,
-				},
-			})
-			if err != nil {
-				slog.Error("Write message error", "error", err)
-				break
-			}
+	return nil
+}
 
-			err = conn.WriteJSON(map[string]any{
-				"status": "end",
-			})
-			if err != nil {
-				slog.Warn("Failed to send end status", "error", err)
-				break
-			}
-			go h.SaveResponce(request, chatRoomID, geminiResp.Explanation, "")
-			continue
+// checkOrigin lets browsers connect only from the origins CORS allows.
+// Requests without an Origin header come from non-browser clients, which
+// authenticate with a bearer token.
+func (h *Handler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || slices.Contains(h.Config.HTTP.AllowedOrigins, origin)
+}
+
+// authorizeChatRoom returns the caller's user ID when the request carries a
+// valid access token of the chat room owner, or the close code to reject
+// it with.
+func (h *Handler) authorizeChatRoom(ctx context.Context, r *http.Request, chatRoomID string) (string, int, error) {
+	if _, err := uuid.Parse(chatRoomID); err != nil {
+		return "", closeBadRequest, errors.New("invalid chat room ID")
+	}
+
+	accessToken := middleware.AccessToken(r)
+	if accessToken == "" {
+		return "", closeUnauthorized, errors.New("missing access token")
+	}
+	claims, err := token.ExtractClaim(accessToken)
+	if err != nil {
+		return "", closeUnauthorized, errors.New("invalid access token")
+	}
+	userID, _ := claims["id"].(string)
+	if userID == "" {
+		return "", closeUnauthorized, errors.New("invalid access token")
+	}
+
+	ownerID, err := h.UseCase.ChatRepo.GetChatRoomOwner(ctx, chatRoomID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", closeForbidden, errors.New("chat room not found")
+	}
+	if err != nil {
+		slog.Error("Failed to get chat room owner", "chat_room_id", chatRoomID, "error", err)
+		return "", websocket.CloseInternalServerErr, errors.New("internal error")
+	}
+	if ownerID != userID {
+		return "", closeForbidden, errors.New("not the chat room owner")
+	}
+
+	return userID, 0, nil
+}
+
+// answerFromDirectory answers from a fresh directory organization the query
+// clearly names. It reports false when there is none and the question has
+// to go to Sonar.
+func (h *Handler) answerFromDirectory(ctx context.Context, answer *wsproto.Message, request, enrichedQuery, chatRoomID, language string, promptVersions map[string]int) (bool, error) {
+	hit, err := directory.Lookup(ctx, h.UseCase.OrganizationRepo, enrichedQuery, h.Config.Directory.MaxAge, h.Config.Directory.MinConfidence)
+	if err != nil {
+		slog.Warn("Directory lookup failed", "error", err)
+		return false, nil
+	}
+	if hit == nil {
+		return false, nil
+	}
+
+	org := directory.ToOrgInfo(hit.Organization)
+	text := directory.Text(hit.Organization, enrichedQuery, language)
+
+	var locations []entity.Location
+	if org.Location.Latitude != 0 || org.Location.Longitude != 0 {
+		locations = []entity.Location{{
+			Latitude:  org.Location.Latitude,
+			Longitude: org.Location.Longitude,
+		}}
+	}
+
+	err = answer.Content(entity.WSContentPayload{
+		Source:        "directory",
+		Text:          text,
+		Citations:     org.Sources,
+		Location:      locations,
+		ImagesURL:     org.ImagesURL,
+		Organizations: []entity.OrgInfo{org},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if err := answer.Done(); err != nil {
+		return false, err
+	}
+
+	slog.Info("Answered from directory", "organization_id", org.ID, "confidence", hit.Confidence)
+	go sonar.SaveResponce(h.UseCase, answer.ID(), request, chatRoomID, text, enrichedQuery, org.Sources, locations, org.ImagesURL, []entity.OrgInfo{org}, nil, promptVersions, false)
+
+	return true, nil
+}
+
+// grounding returns the stored snippets closest to the question, one line
+// each, for the router prompt.
+func (h *Handler) grounding(ctx context.Context, question string) []string {
+	hits, err := h.Retriever.Search(ctx, question, h.Config.Embedding.TopK, h.Config.Embedding.MinScore)
+	if err != nil {
+		slog.Warn("Semantic search failed", "error", err)
+		return nil
+	}
+
+	var res []string
+	for _, hit := range hits {
+		text := []rune(hit.Snippet.Text)
+		if len(text) > 400 {
+			text = append(text[:400], '…')
 		}
+		res = append(res, fmt.Sprintf("- [%s] %s: %s", hit.Snippet.Kind, hit.Snippet.Title, string(text)))
+	}
+	return res
+}
 
-		if geminiResp.ExpectsMultiple {
-			if err := sonar.StreamToWS(*h.Config, h.UseCase, conn, request, geminiResp.EnrichedQuery, chatRoomID); err != nil {
-				_ = conn.WriteJSON(map[string]any{
-					"type":  "error",
-					"error": fmt.Sprintf("Sonar error: %v", err),
-				})
-				return
-			}
-		} else {
-			if err := sonar.StreamToWSOneOrg(*h.Config, h.UseCase, *h.Redis, conn, request, geminiResp.EnrichedQuery, chatRoomID); err != nil {
-				_ = conn.WriteJSON(map[string]any{
-					"type":  "error",
-					"error": fmt.Sprintf("Sonar error: %v", err),
-				})
-				return
-			}
+// answerFromPreviousAnswer repeats a recent vetted answer to a near-duplicate
+// question. It reports false when there is none.
+func (h *Handler) answerFromPreviousAnswer(ctx context.Context, answer *wsproto.Message, request, enrichedQuery, chatRoomID string, promptVersions map[string]int) (bool, error) {
+	hits, err := h.Retriever.Search(ctx, enrichedQuery, h.Config.Embedding.TopK, h.Config.Embedding.ReuseScore, entity.SnippetAnswer)
+	if err != nil {
+		slog.Warn("Semantic search failed", "error", err)
+		return false, nil
+	}
+
+	var prev *embedding.Hit
+	for i, hit := range hits {
+		s := hit.Snippet
+		if s.Kind == entity.SnippetAnswer && s.Vetted && time.Since(s.UpdatedAt) <= h.Config.Embedding.ReuseMaxAge {
+			prev = &hits[i]
+			break
 		}
 	}
+	if prev == nil {
+		return false, nil
+	}
+
+	err = answer.Content(entity.WSContentPayload{
+		Source:    "previous_answer",
+		Text:      prev.Snippet.Text,
+		Citations: prev.Snippet.Sources,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if err := answer.Done(); err != nil {
+		return false, err
+	}
+
+	slog.Info("Answered from previous answer", "chat_id", prev.Snippet.ID, "score", prev.Score)
+	go sonar.SaveResponce(h.UseCase, answer.ID(), request, chatRoomID, prev.Snippet.Text, enrichedQuery, prev.Snippet.Sources, nil, nil, nil, nil, promptVersions, false)
+
+	return true, nil
 }
 
-func (h *Handler) SaveResponce(request, chat_room_id, responce, gemini_request string) {
+// writeAnswerError reports a failed answer to the client and keeps the
+// session open. Transient upstream failures are marked retryable.
+func writeAnswerError(answer *wsproto.Message, language string, err error) error {
+	key := lang.AnswerFailed
+	retryable := httpclient.Retryable(err)
+	if retryable {
+		key = lang.SearchUnavailable
+	}
+
+	return answer.Error(key, lang.T(language, key), retryable)
+}
+
+func (h *Handler) SaveResponce(messageID, request, chat_room_id, responce, gemini_request string, promptVersions map[string]int) {
 
 	h.UseCase.ChatRepo.Create(context.Background(), &entity.ChatCreate{
 		ChatRoomID:    chat_room_id,
@@ -156,5 +536,8 @@ func (h *Handler) SaveResponce(request, chat_room_id, responce, gemini_request s
 		Location:      []string{},
 		ImagesURL:     []string{},
 		Organizations: []entity.OrgInfo{},
+
+		PromptVersions: promptVersions,
+		MessageID:      messageID,
 	})
 }
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"chatbot/internal/usecase"
+)
+
+func TestAuthorizeChatRoomInvalidID(t *testing.T) {
+	// GetChatRoomOwner is not implemented: the ID must be rejected before
+	// the database is asked.
+	h := &Handler{UseCase: &usecase.UseCase{ChatRepo: &fakeChatRepo{}}}
+	r := httptest.NewRequest("GET", "/ws/not-a-uuid", nil)
+	r.Header.Set("Authorization", "Bearer token")
+
+	_, code, err := h.authorizeChatRoom(context.Background(), r, "not-a-uuid")
+	if code != closeBadRequest || err == nil {
+		t.Errorf("authorizeChatRoom() = %d, %v, want %d", code, err, closeBadRequest)
+	}
+}
@@ -0,0 +1,432 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"chatbot/config"
+	"chatbot/internal/entity"
+	"chatbot/internal/usecase"
+	"chatbot/pkg/embedding"
+	"chatbot/pkg/gemini"
+	"chatbot/pkg/lang"
+	"chatbot/pkg/memory"
+	"chatbot/pkg/prompt"
+	"chatbot/pkg/sonar"
+	"chatbot/pkg/wsproto"
+
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	testChatRoomID = "4b8c2a8e-5f0e-4c1b-9d7a-2f6c1e0b3a91"
+	testUserID     = "0c7d5e3f-8a21-4b6e-a4d2-91f3c6b8e570"
+)
+
+type fakeChatRepo struct {
+	usecase.ChatRepoI
+	saved chan *entity.ChatCreate
+}
+
+func (r *fakeChatRepo) Check(ctx context.Context, userID, chatRoomID, language string) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeChatRepo) GetSummary(ctx context.Context, chatRoomID string) (*entity.ChatSummary, error) {
+	return &entity.ChatSummary{ChatRoomID: chatRoomID}, nil
+}
+
+func (r *fakeChatRepo) GetRecentTurns(ctx context.Context, chatRoomID string, limit int) ([]entity.Turn, error) {
+	return nil, nil
+}
+
+func (r *fakeChatRepo) Create(ctx context.Context, req *entity.ChatCreate) error {
+	r.saved <- req
+	return nil
+}
+
+type fakeOrganizationRepo struct {
+	usecase.OrganizationRepoI
+}
+
+func (r fakeOrganizationRepo) FindByNameWords(ctx context.Context, words []string, freshSince time.Time) ([]entity.Organization, error) {
+	return nil, nil
+}
+
+func (r fakeOrganizationRepo) Upsert(ctx context.Context, req *entity.Organization) (string, error) {
+	return "", nil
+}
+
+// failingProvider is the fake provider with a router that is down.
+type failingProvider struct {
+	*gemini.FakeProvider
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *failingProvider) Route(ctx context.Context, req gemini.RouteRequest) (*gemini.GeminiResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	return nil, errors.New("router unavailable")
+}
+
+func (p *failingProvider) routeCalls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+// fakeSearch answers every query with a short text.
+type fakeSearch struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+func (s *fakeSearch) Search(ctx context.Context, req sonar.SearchRequest) (<-chan sonar.Event, error) {
+	s.mu.Lock()
+	s.queries = append(s.queries, req.Query)
+	s.mu.Unlock()
+
+	events := make(chan sonar.Event, 3)
+	events <- sonar.Event{Type: sonar.EventText, Text: "Hamkorbank "}
+	events <- sonar.Event{Type: sonar.EventText, Text: "Andijonda joylashgan."}
+	events <- sonar.Event{Type: sonar.EventDone}
+	close(events)
+	return events, nil
+}
+
+func (s *fakeSearch) lastQuery() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queries) == 0 {
+		return ""
+	}
+	return s.queries[len(s.queries)-1]
+}
+
+// organizationsCache answers the chat organizations lookup with an empty
+// list, unless down. Everything else goes on to the Redis that is not there.
+type organizationsCache struct {
+	down bool
+}
+
+func (c *organizationsCache) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (c *organizationsCache) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() != "zrevrange" || c.down {
+			return next(ctx, cmd)
+		}
+		cmd.(*redis.StringSliceCmd).SetVal(nil)
+		return nil
+	}
+}
+
+func (c *organizationsCache) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+type event struct {
+	Type      string          `json:"type"`
+	MessageID string          `json:"message_id"`
+	Seq       int             `json:"seq"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func newTestHandler(t *testing.T, provider gemini.LLMProvider, opts ...gemini.Option) (*Handler, *fakeChatRepo, *fakeSearch) {
+	t.Helper()
+
+	h, chats, search, _ := newTestHandlerCache(t, provider, opts...)
+	return h, chats, search
+}
+
+func newTestHandlerCache(t *testing.T, provider gemini.LLMProvider, opts ...gemini.Option) (*Handler, *fakeChatRepo, *fakeSearch, *organizationsCache) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.WS.PingInterval = time.Minute
+	cfg.WS.PongWait = time.Minute
+	cfg.WS.WriteWait = time.Second
+	cfg.WS.IdleTimeout = time.Minute
+	cfg.WS.MaxMessageSize = 16384
+	cfg.Directory.MaxAge = time.Hour
+	cfg.Directory.MinConfidence = 0.9
+
+	prompts := prompt.New("../../../../prompts", nil)
+	if err := prompts.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing listens there: the chat cache is skipped as broken.
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { rdb.Close() })
+	orgCache := &organizationsCache{}
+	rdb.AddHook(orgCache)
+
+	chats := &fakeChatRepo{saved: make(chan *entity.ChatCreate, 10)}
+	search := &fakeSearch{}
+	uc := &usecase.UseCase{ChatRepo: chats, OrganizationRepo: fakeOrganizationRepo{}}
+
+	h := &Handler{
+		Config:    cfg,
+		UseCase:   uc,
+		LLM:       gemini.NewService(provider, opts...),
+		Redis:     rdb,
+		Prompts:   prompts,
+		Search:    search,
+		Retriever: embedding.NewRetriever(nil),
+		Memory:    memory.New(chats, rdb, cfg),
+	}
+	return h, chats, search, orgCache
+}
+
+// ask sends question through h.answer over a WebSocket and returns the
+// events the client got.
+func ask(t *testing.T, h *Handler, question string) []event {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{Subprotocols: wsproto.Subprotocols}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		out := wsproto.New(ws, h.Config.WS, nil, testChatRoomID)
+		defer out.Close()
+
+		if err := h.answer(context.Background(), out.Message(), testChatRoomID, testUserID, "uz", question); err != nil {
+			t.Errorf("answer() error = %v", err)
+		}
+		// Let the client read to the end before the connection closes.
+		_, _ = out.Read()
+	}))
+	defer srv.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{"chatbot.v2"}}
+	ws, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	var events []event
+	for {
+		_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var ev event
+		if err := ws.ReadJSON(&ev); err != nil {
+			t.Fatalf("read after %d events: %v", len(events), err)
+		}
+		events = append(events, ev)
+		if ev.Type == entity.WSDone || ev.Type == entity.WSError || ev.Type == entity.WSWarning {
+			return events
+		}
+	}
+}
+
+func content(t *testing.T, events []event) entity.WSContentPayload {
+	t.Helper()
+
+	for _, ev := range events {
+		if ev.Type != entity.WSContent {
+			continue
+		}
+		var res entity.WSContentPayload
+		if err := json.Unmarshal(ev.Payload, &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	t.Fatalf("no content event in %+v", events)
+	return entity.WSContentPayload{}
+}
+
+func saved(t *testing.T, chats *fakeChatRepo) *entity.ChatCreate {
+	t.Helper()
+
+	select {
+	case req := <-chats.saved:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("answer was not saved")
+		return nil
+	}
+}
+
+func TestAnswerRoutedToGemini(t *testing.T) {
+	h, chats, search := newTestHandler(t, gemini.NewFakeProvider())
+
+	events := ask(t, h, "Salom")
+
+	if last := events[len(events)-1]; last.Type != entity.WSDone {
+		t.Fatalf("last event = %q, want done", last.Type)
+	}
+	if got := content(t, events).Text; !strings.HasPrefix(got, "Salom!") {
+		t.Errorf("content = %q, want the router's greeting", got)
+	}
+	if q := search.lastQuery(); q != "" {
+		t.Errorf("Sonar was asked %q, want no search", q)
+	}
+
+	req := saved(t, chats)
+	if req.MessageID != events[0].MessageID || req.UserRequest != "Salom" {
+		t.Errorf("saved %+v, want message %s for the question", req, events[0].MessageID)
+	}
+	if _, ok := req.PromptVersions[prompt.Router]; !ok {
+		t.Errorf("saved prompt versions %v, want the router version", req.PromptVersions)
+	}
+}
+
+func TestAnswerRoutedToSonar(t *testing.T) {
+	h, chats, search := newTestHandler(t, gemini.NewFakeProvider())
+
+	events := ask(t, h, "Hamkorbank qayerda joylashgan")
+
+	if q := search.lastQuery(); q != "Hamkorbank qayerda joylashgan (Uzbekistan)" {
+		t.Errorf("Sonar was asked %q, want the enriched query", q)
+	}
+	if got := content(t, events).Text; got != "Hamkorbank Andijonda joylashgan." {
+		t.Errorf("content = %q", got)
+	}
+	if events[0].Type != entity.WSDelta {
+		t.Errorf("first event = %q, want delta", events[0].Type)
+	}
+	for i, ev := range events {
+		if ev.Seq != i+1 {
+			t.Errorf("event %d has seq %d", i, ev.Seq)
+		}
+	}
+
+	req := saved(t, chats)
+	if req.GeminiRequest != "Hamkorbank qayerda joylashgan (Uzbekistan)" || req.Interrupted {
+		t.Errorf("saved %+v", req)
+	}
+}
+
+func TestAnswerRouterError(t *testing.T) {
+	provider := &failingProvider{FakeProvider: gemini.NewFakeProvider()}
+	h, chats, search := newTestHandler(t, provider)
+
+	events := ask(t, h, "Hamkorbank qayerda joylashgan")
+
+	// The question goes to Sonar as it was asked.
+	if q := search.lastQuery(); q != "Hamkorbank qayerda joylashgan" {
+		t.Errorf("Sonar was asked %q, want the question as is", q)
+	}
+	if last := events[len(events)-1]; last.Type != entity.WSDone {
+		t.Fatalf("last event = %q, want done", last.Type)
+	}
+	if provider.routeCalls() != 1 {
+		t.Errorf("router called %d times, want 1", provider.routeCalls())
+	}
+
+	req := saved(t, chats)
+	if _, ok := req.PromptVersions[prompt.Router]; ok {
+		t.Errorf("saved prompt versions %v, want no router version", req.PromptVersions)
+	}
+}
+
+func TestAnswerBreakerOpen(t *testing.T) {
+	provider := &failingProvider{FakeProvider: gemini.NewFakeProvider()}
+	h, _, search := newTestHandler(t, provider, gemini.Breaker(1, time.Hour))
+
+	ask(t, h, "Hamkorbank qayerda joylashgan")
+	if provider.routeCalls() != 1 {
+		t.Fatalf("router called %d times, want 1", provider.routeCalls())
+	}
+
+	// The breaker is open now: the router is not asked, the answer still
+	// comes from Sonar.
+	events := ask(t, h, "Kapitalbank telefon raqami")
+
+	if provider.routeCalls() != 1 {
+		t.Errorf("router called %d times with the breaker open, want 1", provider.routeCalls())
+	}
+	if q := search.lastQuery(); q != "Kapitalbank telefon raqami" {
+		t.Errorf("Sonar was asked %q, want the question as is", q)
+	}
+	if last := events[len(events)-1]; last.Type != entity.WSDone {
+		t.Fatalf("last event = %q, want done", last.Type)
+	}
+}
+
+func TestAnswerOrganizationsCacheDown(t *testing.T) {
+	h, chats, search, orgCache := newTestHandlerCache(t, gemini.NewFakeProvider())
+	orgCache.down = true
+
+	events := ask(t, h, "Hamkorbank qayerda joylashgan")
+
+	last := events[len(events)-1]
+	if last.Type != entity.WSError {
+		t.Fatalf("last event = %q, want error", last.Type)
+	}
+	var payload entity.WSErrorPayload
+	if err := json.Unmarshal(last.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Code != lang.SearchUnavailable || !payload.Retryable {
+		t.Errorf("error = %+v, want retryable %s", payload, lang.SearchUnavailable)
+	}
+	if q := search.lastQuery(); q != "" {
+		t.Errorf("Sonar was asked %q, want no search", q)
+	}
+	select {
+	case req := <-chats.saved:
+		t.Errorf("saved %+v, want nothing saved", req)
+	default:
+	}
+}
+
+func TestAnswerFromPreviousAnswer(t *testing.T) {
+	tests := []struct {
+		name   string
+		vetted bool
+		want   string
+	}{
+		{name: "vetted answer reused", vetted: true, want: "previous_answer"},
+		{name: "interrupted answer not reused", vetted: false, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, search := newTestHandler(t, gemini.NewFakeProvider())
+			h.Config.Embedding.TopK = 3
+			h.Config.Embedding.ReuseScore = 0.8
+			h.Config.Embedding.ReuseMaxAge = time.Hour
+			h.Retriever = embedding.NewRetriever(embedding.NewHash(256))
+			err := h.Retriever.Add(context.Background(), []entity.Snippet{{
+				ID:        "1f6c0e2a-3b7d-4c59-8e41-a2d9b6f07c35",
+				Kind:      entity.SnippetAnswer,
+				Title:     "Hamkorbank qayerda joylashgan (Uzbekistan)",
+				Text:      "Hamkorbank Andijonda",
+				Sources:   []string{"https://hamkorbank.uz"},
+				Vetted:    tt.vetted,
+				UpdatedAt: time.Now(),
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := content(t, ask(t, h, "Hamkorbank qayerda joylashgan"))
+
+			if got.Source != tt.want {
+				t.Errorf("content source = %q, want %q", got.Source, tt.want)
+			}
+			if asked := search.lastQuery() != ""; asked == tt.vetted {
+				t.Errorf("Sonar asked = %v, want %v", asked, !tt.vetted)
+			}
+		})
+	}
+}
@@ -4,25 +4,40 @@ import (
 	"chatbot/config"
 	"chatbot/internal/usecase"
 
-	"github.com/google/generative-ai-go/genai"
 	"github.com/redis/go-redis/v9"
+	"chatbot/pkg/coords"
+	"chatbot/pkg/embedding"
+	"chatbot/pkg/gemini"
+	"chatbot/pkg/memory"
 	"chatbot/pkg/minio"
+	"chatbot/pkg/prompt"
+	"chatbot/pkg/sonar"
 )
 
 type Handler struct {
 	Config       *config.Config
 	UseCase      *usecase.UseCase
-	GeminiClient *genai.Client
+	LLM          *gemini.Service
 	Redis        *redis.Client
 	MinIO        *minio.MinIO
+	Prompts      *prompt.Registry
+	Search       sonar.SearchProvider
+	Coords       *coords.Resolver
+	Retriever    *embedding.Retriever
+	Memory       *memory.ConversationMemory
 }
 
-func NewHandler(c *config.Config, useCase *usecase.UseCase, geminiClient *genai.Client, rdb *redis.Client, mn minio.MinIO) *Handler {
+func NewHandler(c *config.Config, useCase *usecase.UseCase, llm *gemini.Service, rdb *redis.Client, mn minio.MinIO, prompts *prompt.Registry, search sonar.SearchProvider, resolver *coords.Resolver, retriever *embedding.Retriever, mem *memory.ConversationMemory) *Handler {
 	return &Handler{
 		Config:       c,
 		UseCase:      useCase,
-		GeminiClient: geminiClient,
+		LLM:          llm,
 		Redis:        rdb,
 		MinIO:        &mn,
+		Prompts:      prompts,
+		Search:       search,
+		Coords:       resolver,
+		Retriever:    retriever,
+		Memory:       mem,
 	}
 }
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"chatbot/internal/entity"
+	"chatbot/pkg/kb"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UploadKBDocument godoc
+// @Summary Upload a knowledge base document
+// @Description Upload an official document (.txt, .md, .pdf or .docx). Its text is chunked and indexed, and chat answers cite it ahead of web results.
+// @Tags Knowledge base
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Document"
+// @Param title formData string false "Title (default: file name)"
+// @Param tags formData string false "Comma-separated tags"
+// @Success 200 {object} entity.KBDocument
+// @Failure 400 {string} string "Invalid request"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /kb/documents/upload [post]
+func (h *Handler) UploadKBDocument(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File not provided"})
+		slog.Error("Error reading document", "err", err)
+		return
+	}
+	defer file.Close()
+
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	contentType, ok := kb.Extensions[ext]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unsupported document type: %s", ext)})
+		return
+	}
+	if header.Size > h.Config.KB.MaxFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("document is larger than %d bytes", h.Config.KB.MaxFileSize)})
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, h.Config.KB.MaxFileSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		slog.Error("Error reading document", "err", err)
+		return
+	}
+
+	text, err := kb.Extract(header.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Error extracting document text", "file", header.Filename, "err", err)
+		return
+	}
+	chunks := kb.Chunk(text, h.Config.KB.ChunkSize, h.Config.KB.ChunkOverlap)
+
+	fileName := uuid.NewString() + ext
+	tempFilePath := filepath.Join(os.TempDir(), fileName)
+	if err := os.WriteFile(tempFilePath, data, 0o600); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create temporary file"})
+		slog.Error("Error creating temporary file", "err", err)
+		return
+	}
+	defer os.Remove(tempFilePath)
+
+	url, err := h.MinIO.Upload(fileName, tempFilePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to MinIO"})
+		slog.Error("Error uploading to MinIO", "err", err)
+		return
+	}
+
+	title := strings.TrimSpace(c.PostForm("title"))
+	if title == "" {
+		title = strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
+	}
+
+	ctx := context.Background()
+	doc, err := h.UseCase.KBRepo.Create(ctx, &entity.KBDocument{
+		Title:       title,
+		FileName:    header.Filename,
+		URL:         url,
+		ContentType: contentType,
+		Tags:        parseTags(c.PostForm("tags")),
+		UploadedBy:  c.GetString("id"),
+	}, chunks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error("Create document error", "err", err)
+		return
+	}
+
+	// Index right away rather than on the next periodic sync.
+	if err := h.Retriever.Sync(ctx); err != nil {
+		slog.Warn("Semantic index sync failed", "err", err)
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+// GetKBDocuments godoc
+// @Summary Get knowledge base documents
+// @Description Get the uploaded documents, newest first
+// @Tags Knowledge base
+// @Accept  json
+// @Produce  json
+// @Param tag query string false "Tag"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} entity.KBDocumentList
+// @Failure 400 {string} string "Invalid request"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /kb/documents/list [get]
+func (h *Handler) GetKBDocuments(c *gin.Context) {
+	limit, offset, err := parsePaginationParams(c, c.Query("limit"), c.Query("offset"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Pagination parse error", "err", err)
+		return
+	}
+
+	res, err := h.UseCase.KBRepo.GetAll(context.Background(), strings.TrimSpace(c.Query("tag")), &entity.Filter{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error("Get documents error", "err", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// DeleteKBDocument godoc
+// @Summary Delete a knowledge base document
+// @Description Delete a document; answers stop citing it after the next index sync
+// @Tags Knowledge base
+// @Accept  json
+// @Produce  json
+// @Param id query string true "Document ID"
+// @Success 200 {object} string
+// @Failure 400 {string} string "Invalid request"
+// @Security BearerAuth
+// @Router /kb/documents/delete [delete]
+func (h *Handler) DeleteKBDocument(c *gin.Context) {
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		slog.Error("Invalid document delete input")
+		return
+	}
+
+	ctx := context.Background()
+	if err := h.UseCase.KBRepo.Delete(ctx, id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Delete document error", "err", err)
+		return
+	}
+
+	if err := h.Retriever.Sync(ctx); err != nil {
+		slog.Warn("Semantic index sync failed", "err", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "document deleted"})
+}
+
+// documentCitations returns the knowledge base chunks closest to the query
+// as citations, one per document.
+func (h *Handler) documentCitations(ctx context.Context, query string) []entity.DocumentCitation {
+	hits, err := h.Retriever.Search(ctx, query, h.Config.KB.TopK, h.Config.KB.MinScore, entity.SnippetDocument)
+	if err != nil {
+		slog.Warn("Document search failed", "error", err)
+		return nil
+	}
+
+	var res []entity.DocumentCitation
+	seen := map[string]bool{}
+	for _, hit := range hits {
+		s := hit.Snippet
+		if seen[s.ParentID] {
+			continue
+		}
+		seen[s.ParentID] = true
+
+		citation := entity.DocumentCitation{
+			Type:       entity.SnippetDocument,
+			DocumentID: s.ParentID,
+			Title:      s.Title,
+			Tags:       s.Tags,
+			Excerpt:    s.Text,
+		}
+		if len(s.Sources) > 0 {
+			citation.URL = s.Sources[0]
+		}
+		res = append(res, citation)
+	}
+	return res
+}
+
+func parseTags(s string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
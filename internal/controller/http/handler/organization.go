@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"chatbot/internal/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit  = 20
+	defaultSearchRadius = 5000
+	maxSearchRadius     = 50000
+)
+
+// SearchOrganizations godoc
+// @Summary Search organizations
+// @Description Search the organization directory by text, industry, city and distance. Results use the same shape as the organizations streamed over the WebSocket.
+// @Tags Organizations
+// @Accept  json
+// @Produce  json
+// @Param q query string false "Full-text query over name and description"
+// @Param industry query string false "Industry"
+// @Param city query string false "City"
+// @Param near query string false "Center point as lat,lng"
+// @Param radius query number false "Radius around near in meters (default 5000, max 50000)"
+// @Param limit query int false "Limit (default 20)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} entity.OrgInfoList
+// @Failure 400 {string} string "Invalid request"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations [get]
+func (h *Handler) SearchOrganizations(c *gin.Context) {
+	limit, offset, err := parsePaginationParams(c, c.Query("limit"), c.Query("offset"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Pagination parse error", "err", err)
+		return
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	req := &entity.OrganizationSearch{
+		Query:    strings.TrimSpace(c.Query("q")),
+		Industry: strings.TrimSpace(c.Query("industry")),
+		City:     strings.TrimSpace(c.Query("city")),
+		Limit:    limit,
+		Offset:   offset,
+	}
+
+	if near := c.Query("near"); near != "" {
+		req.Latitude, req.Longitude, err = parseNear(near)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			slog.Error("Invalid near parameter", "near", near, "err", err)
+			return
+		}
+		req.Near = true
+
+		req.Radius = defaultSearchRadius
+		if radius := c.Query("radius"); radius != "" {
+			req.Radius, err = strconv.ParseFloat(radius, 64)
+			if err != nil || req.Radius <= 0 || req.Radius > maxSearchRadius {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "radius must be between 0 and 50000 meters"})
+				slog.Error("Invalid radius parameter", "radius", radius)
+				return
+			}
+		}
+	}
+
+	res, err := h.UseCase.OrganizationRepo.Search(context.Background(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error("Search organizations error", "err", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// parseNear parses "lat,lng".
+func parseNear(near string) (float64, float64, error) {
+	parts := strings.Split(near, ",")
+	if len(parts) != 2 {
+		return 0, 0, errors.New("near must be lat,lng")
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, errors.New("invalid latitude in near")
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return 0, 0, errors.New("invalid longitude in near")
+	}
+
+	return lat, lng, nil
+}
+
+// GetMergeCandidates godoc
+// @Summary Get organization merge candidates
+// @Description Get possible duplicate organizations found by the matcher, best matches first
+// @Tags Organizations
+// @Accept  json
+// @Produce  json
+// @Param status query string false "pending, confirmed, rejected or obsolete"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} entity.MergeCandidateList
+// @Failure 400 {string} string "Invalid request"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/merges [get]
+func (h *Handler) GetMergeCandidates(c *gin.Context) {
+	limit, offset, err := parsePaginationParams(c, c.Query("limit"), c.Query("offset"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Pagination parse error", "err", err)
+		return
+	}
+
+	res, err := h.UseCase.OrganizationRepo.GetMergeCandidates(context.Background(), c.Query("status"), &entity.Filter{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error("Get merge candidates error", "err", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// ConfirmMerge godoc
+// @Summary Confirm an organization merge
+// @Description Merge the duplicate into the organization and remove the duplicate from the directory
+// @Tags Organizations
+// @Accept  json
+// @Produce  json
+// @Param id query string true "Merge candidate ID"
+// @Success 200 {object} string
+// @Failure 400 {string} string "Invalid request"
+// @Security BearerAuth
+// @Router /organizations/merges/confirm [put]
+func (h *Handler) ConfirmMerge(c *gin.Context) {
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		slog.Error("Invalid merge confirm input")
+		return
+	}
+
+	if err := h.UseCase.OrganizationRepo.ConfirmMerge(context.Background(), id, c.GetString("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Confirm merge error", "err", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "organizations merged"})
+}
+
+// RejectMerge godoc
+// @Summary Reject an organization merge
+// @Description Mark the candidate as two different organizations
+// @Tags Organizations
+// @Accept  json
+// @Produce  json
+// @Param id query string true "Merge candidate ID"
+// @Success 200 {object} string
+// @Failure 400 {string} string "Invalid request"
+// @Security BearerAuth
+// @Router /organizations/merges/reject [put]
+func (h *Handler) RejectMerge(c *gin.Context) {
+	id := c.Query("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		slog.Error("Invalid merge reject input")
+		return
+	}
+
+	if err := h.UseCase.OrganizationRepo.RejectMerge(context.Background(), id, c.GetString("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Reject merge error", "err", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "merge rejected"})
+}
+
+// GetOrganizationRevisions godoc
+// @Summary Get organization revisions
+// @Description Get the facts that changed when organizations were re-verified, newest first
+// @Tags Organizations
+// @Accept  json
+// @Produce  json
+// @Param organization_id query string false "Organization ID"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} entity.OrganizationRevisionList
+// @Failure 400 {string} string "Invalid request"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /organizations/revisions [get]
+func (h *Handler) GetOrganizationRevisions(c *gin.Context) {
+	limit, offset, err := parsePaginationParams(c, c.Query("limit"), c.Query("offset"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Pagination parse error", "err", err)
+		return
+	}
+
+	res, err := h.UseCase.OrganizationRepo.GetRevisions(context.Background(), c.Query("organization_id"), &entity.Filter{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error("Get organization revisions error", "err", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
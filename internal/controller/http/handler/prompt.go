@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"chatbot/internal/entity"
+	"chatbot/pkg/prompt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+)
+
+// GetAllPrompts godoc
+// @Summary Get all prompt versions
+// @Description Get stored prompt versions, optionally filtered by name
+// @Tags Prompts
+// @Accept  json
+// @Produce  json
+// @Param name query string false "Prompt name"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} entity.PromptList
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /prompts/list [get]
+func (h *Handler) GetAllPrompts(c *gin.Context) {
+	limit, offset, err := parsePaginationParams(c, c.Query("limit"), c.Query("offset"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Pagination parse error", "err", err)
+		return
+	}
+
+	res, err := h.UseCase.PromptRepo.GetAll(context.Background(), c.Query("name"), &entity.Filter{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error("Get all prompts error", "err", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// CreatePrompt godoc
+// @Summary Create a prompt version
+// @Description Store a new version of a prompt. The body is test-rendered with the data the prompt gets. The version is not active until it is activated.
+// @Tags Prompts
+// @Accept  json
+// @Produce  json
+// @Param prompt body entity.CreatePrompt true "Prompt data"
+// @Success 200 {object} entity.Prompt
+// @Failure 400 {string} string "Invalid request"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /prompts/create [post]
+func (h *Handler) CreatePrompt(c *gin.Context) {
+	var req entity.CreatePrompt
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Invalid prompt input", "err", err)
+		return
+	}
+
+	if err := prompt.Check(req.Name, req.Body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Invalid prompt template", "err", err)
+		return
+	}
+
+	res, err := h.UseCase.PromptRepo.Create(context.Background(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error("Create prompt error", "err", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// PreviewPrompt godoc
+// @Summary Preview a prompt
+// @Description Render a prompt with the given data, or with sample data when none is given. Uses body if given, otherwise the stored version, otherwise the active one.
+// @Tags Prompts
+// @Accept  json
+// @Produce  json
+// @Param prompt body entity.PreviewPrompt true "Preview data"
+// @Success 200 {object} entity.PreviewPromptRes
+// @Failure 400 {string} string "Invalid request"
+// @Failure 404 {string} string "Prompt version not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /prompts/preview [post]
+func (h *Handler) PreviewPrompt(c *gin.Context) {
+	var req entity.PreviewPrompt
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Invalid prompt preview input", "err", err)
+		return
+	}
+
+	data, err := prompt.Sample(req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Invalid prompt preview input", "err", err)
+		return
+	}
+	if len(req.Data) > 0 {
+		data = req.Data
+	}
+
+	body := req.Body
+	if body == "" && req.Version != 0 {
+		stored, err := h.UseCase.PromptRepo.GetByVersion(context.Background(), req.Name, req.Version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "prompt version not found"})
+			slog.Error("Prompt version not found", "name", req.Name, "version", req.Version)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			slog.Error("Get prompt version error", "err", err)
+			return
+		}
+		body = stored.Body
+	}
+
+	var text string
+	if body != "" {
+		text, err = prompt.Preview(req.Name, body, data)
+	} else {
+		text, _, err = h.Prompts.Render(req.Name, data)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Prompt preview error", "err", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.PreviewPromptRes{Text: text})
+}
+
+// ActivatePrompt godoc
+// @Summary Activate a prompt version
+// @Description Make the given version the active one. The body is test-rendered with the data the prompt gets first. Version 0 falls back to the template file.
+// @Tags Prompts
+// @Accept  json
+// @Produce  json
+// @Param name query string true "Prompt name"
+// @Param version query int true "Prompt version"
+// @Success 200 {object} string
+// @Failure 400 {string} string "Invalid request"
+// @Failure 404 {string} string "Prompt version not found"
+// @Failure 500 {string} string "Internal server error"
+// @Security BearerAuth
+// @Router /prompts/activate [put]
+func (h *Handler) ActivatePrompt(c *gin.Context) {
+	name := c.Query("name")
+	version, err := strconv.Atoi(c.Query("version"))
+	if name == "" || err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and version are required"})
+		slog.Error("Invalid prompt activate input", "name", name, "version", c.Query("version"))
+		return
+	}
+
+	if _, err := prompt.Sample(name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.Error("Invalid prompt activate input", "err", err)
+		return
+	}
+
+	if version != 0 {
+		stored, err := h.UseCase.PromptRepo.GetByVersion(context.Background(), name, version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "prompt version not found"})
+			slog.Error("Prompt version not found", "name", name, "version", version)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			slog.Error("Get prompt version error", "err", err)
+			return
+		}
+		// Stored before rendering was checked, or against an older data shape.
+		if err := prompt.Check(name, stored.Body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			slog.Error("Invalid prompt template", "err", err)
+			return
+		}
+	}
+
+	if err := h.UseCase.PromptRepo.Activate(context.Background(), name, version); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error("Activate prompt error", "err", err)
+		return
+	}
+
+	if err := h.Prompts.Load(context.Background()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error("Prompt reload error", "err", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "prompt activated"})
+}
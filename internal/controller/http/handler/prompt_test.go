@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"chatbot/internal/entity"
+	"chatbot/internal/usecase"
+	"chatbot/pkg/prompt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+)
+
+type fakePromptRepo struct {
+	usecase.PromptRepoI
+	err error
+}
+
+func (r fakePromptRepo) GetByVersion(ctx context.Context, name string, version int) (*entity.Prompt, error) {
+	return nil, r.err
+}
+
+func TestPromptVersionLookup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "missing version", err: pgx.ErrNoRows, want: http.StatusNotFound},
+		{name: "database down", err: errors.New("connection refused"), want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{UseCase: &usecase.UseCase{PromptRepo: fakePromptRepo{err: tt.err}}}
+			engine := gin.New()
+			engine.POST("/prompts/preview", h.PreviewPrompt)
+			engine.PUT("/prompts/activate", h.ActivatePrompt)
+
+			requests := []*http.Request{
+				httptest.NewRequest(http.MethodPost, "/prompts/preview", strings.NewReader(`{"name":"`+prompt.Router+`","version":7}`)),
+				httptest.NewRequest(http.MethodPut, "/prompts/activate?name="+prompt.Router+"&version=7", nil),
+			}
+			for _, r := range requests {
+				w := httptest.NewRecorder()
+				engine.ServeHTTP(w, r)
+				if w.Code != tt.want {
+					t.Errorf("%s %s = %d, want %d: %s", r.Method, r.URL.Path, w.Code, tt.want, w.Body)
+				}
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"chatbot/internal/entity"
 	"chatbot/pkg/auth"
 	"chatbot/pkg/cache"
+	"chatbot/pkg/lang"
 
 	"github.com/gin-gonic/gin"
 )
@@ -433,6 +434,7 @@ func (h *Handler) GetMe(c *gin.Context) {
 		c.Request.Context(),
 		id,
 		"",
+		lang.Resolve("", res.Language),
 	)
 
 	res.Role = role
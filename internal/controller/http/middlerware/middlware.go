@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"chatbot/internal/controller/http/token"
@@ -58,6 +59,20 @@ func Identity(userRepo usecase.UserRepoI) gin.HandlerFunc {
 	}
 }
 
+// AccessToken returns the token from the access_token cookie or, for
+// clients without cookies, the Authorization bearer header.
+func AccessToken(r *http.Request) string {
+	if cookie, err := r.Cookie("access_token"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	scheme, value, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
+
 func Authorize(enforcer *casbin.Enforcer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
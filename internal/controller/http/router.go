@@ -9,7 +9,6 @@ import (
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/google/generative-ai-go/genai"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/v9"
 
@@ -23,7 +22,13 @@ import (
 
 	// middleware "chatbot/internal/controller/http/middlerware"
 	"chatbot/internal/usecase"
+	"chatbot/pkg/coords"
+	"chatbot/pkg/embedding"
+	"chatbot/pkg/gemini"
+	"chatbot/pkg/memory"
 	"chatbot/pkg/minio"
+	"chatbot/pkg/prompt"
+	"chatbot/pkg/sonar"
 )
 
 func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
@@ -45,24 +50,16 @@ func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-func NewRouter(engine *gin.Engine, config *config.Config, useCase *usecase.UseCase, gemini_client *genai.Client, rdb *redis.Client, minioClient *minio.MinIO) {
+func NewRouter(engine *gin.Engine, config *config.Config, useCase *usecase.UseCase, llm *gemini.Service, rdb *redis.Client, minioClient *minio.MinIO, prompts *prompt.Registry, search sonar.SearchProvider, resolver *coords.Resolver, retriever *embedding.Retriever, mem *memory.ConversationMemory) {
 	// Options
 	engine.Use(gin.Logger())
 	// engine.Use(gin.Recovery())
 
-	handlerV1 := handler.NewHandler(config, useCase, gemini_client, rdb, *minioClient)
+	handlerV1 := handler.NewHandler(config, useCase, llm, rdb, *minioClient, prompts, search, resolver, retriever, mem)
 	// Initialize Casbin enforcer
 
 	engine.Use(cors.New(cors.Config{
-		AllowOrigins: []string{
-			"http://localhost:3000",
-			"http://localhost:5050",
-			"https://ai-1009.ccenter.uz",
-			"https://1009-ai.kontaktmarkazi.uz",
-			"https://kontakt-chatbot.kontaktmarkazi.uz",
-			"https://1009-chatbot.kontaktmarkazi.uz",
-			"https://back-ai.ccenter.uz",
-		},
+		AllowOrigins:     config.HTTP.AllowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
 		ExposeHeaders:    []string{"Content-Length"},
@@ -134,6 +131,30 @@ func NewRouter(engine *gin.Engine, config *config.Config, useCase *usecase.UseCa
 		chat.GET("/message", handlerV1.GetChatRoomChat)
 	}
 
+	prompt := engine.Group("/prompts")
+	{
+		prompt.GET("/list", handlerV1.GetAllPrompts)
+		prompt.POST("/create", handlerV1.CreatePrompt)
+		prompt.POST("/preview", handlerV1.PreviewPrompt)
+		prompt.PUT("/activate", handlerV1.ActivatePrompt)
+	}
+
+	organizations := engine.Group("/organizations")
+	{
+		organizations.GET("", handlerV1.SearchOrganizations)
+		organizations.GET("/merges", handlerV1.GetMergeCandidates)
+		organizations.PUT("/merges/confirm", handlerV1.ConfirmMerge)
+		organizations.PUT("/merges/reject", handlerV1.RejectMerge)
+		organizations.GET("/revisions", handlerV1.GetOrganizationRevisions)
+	}
+
+	kb := engine.Group("/kb")
+	{
+		kb.POST("/documents/upload", handlerV1.UploadKBDocument)
+		kb.GET("/documents/list", handlerV1.GetKBDocuments)
+		kb.DELETE("/documents/delete", handlerV1.DeleteKBDocument)
+	}
+
 	// dashboard := engine.Group("/dashboard")
 	// {
 	// 	dashboard.GET("/active-users", handlerV1.DashboardActiveUsers)
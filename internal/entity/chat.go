@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 type ChatCreate struct {
 	Id            string   `json:"id"`
 	ChatRoomID    string   `json:"chat_room_id" binding:"required"`
@@ -10,6 +12,15 @@ type ChatCreate struct {
 	ImagesURL     []string `json:"images_url" binding:"required"`
 	Organizations any      `json:"organizations" binding:"required"`
 	CitationURLs  []string `json:"citation_urls" binding:"required"`
+
+	Documents      []DocumentCitation `json:"documents"`
+	PromptVersions map[string]int     `json:"prompt_versions"`
+
+	// Interrupted marks an answer the user cancelled; Responce holds what
+	// was streamed before.
+	Interrupted bool `json:"interrupted"`
+	// MessageID is the WebSocket message ID the answer was streamed under.
+	MessageID string `json:"message_id"`
 }
 
 type ChatRoomCreate struct {
@@ -49,6 +60,8 @@ type ContentRes struct {
 	Location      []map[string]float64 `json:"location,omitempty"`
 	ImagesURL     []string             `json:"images_url,omitempty"`
 	Organizations any                  `json:"organizations,omitempty"`
+	Documents     []DocumentCitation   `json:"documents,omitempty"`
+	Interrupted   bool                 `json:"interrupted,omitempty"`
 }
 
 type Content struct {
@@ -78,6 +91,7 @@ type MessageResponse struct {
 }
 
 type OrgInfo struct {
+	ID       string `json:"id,omitempty"`
 	Name     string `json:"name"`
 	Address  string `json:"address"`
 	Location struct {
@@ -97,7 +111,14 @@ type Response struct {
 	Data      []OrgInfo `json:"data"`
 }
 
+// Client message types. A message without a type is a question.
+const (
+	RequestQuestion = "question"
+	RequestCancel   = "cancel"
+)
+
 type Request struct {
+	Type    string `json:"type"`
 	Message string `json:"message"`
 }
 
@@ -110,3 +131,32 @@ type GetChatRoomReq struct {
 	Limit  int    `json:"limit"`
 	Offset int    `json:"offset"`
 }
+
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Turn is one message of a conversation as the models see it.
+type Turn struct {
+	Role      string    `json:"role"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatSummary is the rolling summary of the older exchanges of a chat room.
+type ChatSummary struct {
+	ChatRoomID string `json:"chat_room_id"`
+	Text       string `json:"text"`
+
+	// Exchanges is how many of the oldest exchanges the summary covers,
+	// Total how many the room has.
+	Exchanges int `json:"exchanges"`
+	Total     int `json:"total"`
+}
+
+// Conversation is the context of a chat room given to the router.
+type Conversation struct {
+	Summary string `json:"summary"`
+	Turns   []Turn `json:"turns"`
+}
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// GeoCache is a resolved (or failed) map URL.
+type GeoCache struct {
+	Key        string    `json:"key"`
+	URL        string    `json:"url"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Source     string    `json:"source"`
+	Failed     bool      `json:"failed"`
+	ResolvedAt time.Time `json:"resolved_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -0,0 +1,30 @@
+package entity
+
+type KBDocument struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	FileName    string   `json:"file_name"`
+	URL         string   `json:"url"`
+	ContentType string   `json:"content_type"`
+	Tags        []string `json:"tags"`
+	ChunkCount  int      `json:"chunk_count"`
+	UploadedBy  string   `json:"uploaded_by"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+type KBDocumentList struct {
+	Documents []KBDocument `json:"documents"`
+	Count     int          `json:"count"`
+}
+
+// DocumentCitation cites a knowledge base document in an answer, apart from
+// the web citations.
+type DocumentCitation struct {
+	Type       string   `json:"type"`
+	DocumentID string   `json:"document_id"`
+	Title      string   `json:"title"`
+	URL        string   `json:"url"`
+	Tags       []string `json:"tags,omitempty"`
+	Excerpt    string   `json:"excerpt"`
+}
@@ -0,0 +1,105 @@
+package entity
+
+import "time"
+
+type Organization struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Industry    string `json:"industry"`
+	FoundedYear int    `json:"founded_year"`
+
+	Address string `json:"address"`
+	City    string `json:"city"`
+	Country string `json:"country"`
+
+	Phone       string            `json:"phone"`
+	Email       string            `json:"email"`
+	Website     string            `json:"website"`
+	SocialMedia map[string]string `json:"social_media"`
+
+	TaxID              string `json:"tax_id"`
+	RegistrationNumber string `json:"registration_number"`
+
+	Location struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"location"`
+
+	Sources   []string `json:"sources"`
+	ImagesURL []string `json:"images_url"`
+
+	// FieldSources records, per field, when and where its value was last
+	// observed.
+	FieldSources map[string]FieldSource `json:"field_sources"`
+
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+	VerifiedAt string `json:"verified_at"`
+}
+
+type FieldSource struct {
+	ObservedAt time.Time `json:"observed_at"`
+	SourceURL  string    `json:"source_url"`
+}
+
+// OrganizationRevision is a field value changed by re-verification.
+type OrganizationRevision struct {
+	ID             string `json:"id"`
+	OrganizationID string `json:"organization_id"`
+	Organization   string `json:"organization"`
+	Field          string `json:"field"`
+	OldValue       string `json:"old_value"`
+	NewValue       string `json:"new_value"`
+	SourceURL      string `json:"source_url"`
+	ObservedAt     string `json:"observed_at"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type OrganizationRevisionList struct {
+	Revisions []OrganizationRevision `json:"revisions"`
+	Count     int                    `json:"count"`
+}
+
+type OrganizationRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type MergeCandidate struct {
+	ID           string          `json:"id"`
+	Organization OrganizationRef `json:"organization"`
+	Duplicate    OrganizationRef `json:"duplicate"`
+	Confidence   float64         `json:"confidence"`
+	Reasons      []string        `json:"reasons"`
+	Status       string          `json:"status"`
+	DecidedBy    string          `json:"decided_by"`
+	CreatedAt    string          `json:"created_at"`
+	DecidedAt    string          `json:"decided_at"`
+}
+
+type MergeCandidateList struct {
+	Candidates []MergeCandidate `json:"candidates"`
+	Count      int              `json:"count"`
+}
+
+type OrganizationSearch struct {
+	Query    string
+	Industry string
+	City     string
+
+	// Near is set when Latitude and Longitude are given; Radius is in
+	// meters.
+	Near      bool
+	Latitude  float64
+	Longitude float64
+	Radius    float64
+
+	Limit  int
+	Offset int
+}
+
+type OrgInfoList struct {
+	Organizations []OrgInfo `json:"organizations"`
+	Count         int       `json:"count"`
+}
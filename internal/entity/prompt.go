@@ -0,0 +1,31 @@
+package entity
+
+type Prompt struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Version   int    `json:"version"`
+	Body      string `json:"body"`
+	IsActive  bool   `json:"is_active"`
+	CreatedAt string `json:"created_at"`
+}
+
+type CreatePrompt struct {
+	Name string `json:"name" binding:"required"`
+	Body string `json:"body" binding:"required"`
+}
+
+type PromptList struct {
+	Prompts []Prompt `json:"prompts"`
+	Count   int      `json:"count"`
+}
+
+type PreviewPrompt struct {
+	Name    string         `json:"name" binding:"required"`
+	Body    string         `json:"body"`
+	Version int            `json:"version"`
+	Data    map[string]any `json:"data"`
+}
+
+type PreviewPromptRes struct {
+	Text string `json:"text"`
+}
@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+const (
+	SnippetAnswer       = "answer"
+	SnippetOrganization = "organization"
+	SnippetDocument     = "document"
+)
+
+// Snippet is a stored text the semantic index is built from: a past answer,
+// an organization record or a chunk of a knowledge base document.
+type Snippet struct {
+	ID      string   `json:"id"`
+	Kind    string   `json:"kind"`
+	Title   string   `json:"title"`
+	Text    string   `json:"text"`
+	Sources []string `json:"sources"`
+	Tags    []string `json:"tags,omitempty"`
+
+	// ParentID is the document of a document chunk.
+	ParentID string `json:"parent_id,omitempty"`
+
+	// Vetted answers are complete, backed by citations and may be reused
+	// as is.
+	Vetted    bool      `json:"vetted"`
+	Deleted   bool      `json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
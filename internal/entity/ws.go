@@ -0,0 +1,80 @@
+package entity
+
+// WebSocket event types, see docs/websocket.md.
+const (
+	WSDelta        = "delta"
+	WSContent      = "content"
+	WSOrganization = "organization"
+	WSCitation     = "citation"
+	WSLocation     = "location"
+	WSWarning      = "warning"
+	WSError        = "error"
+	WSDone         = "done"
+	WSCancelled    = "cancelled"
+)
+
+// WSEnvelope wraps every event the chat WebSocket sends. The events of one
+// answer share a MessageID and are numbered by Seq from 1.
+type WSEnvelope struct {
+	Type      string `json:"type" enums:"delta,content,organization,citation,location,warning,error,done,cancelled"`
+	MessageID string `json:"message_id"`
+	Seq       int    `json:"seq"`
+	Payload   any    `json:"payload"`
+}
+
+// WSDeltaPayload is the next chunk of a streamed answer text.
+type WSDeltaPayload struct {
+	Text string `json:"text"`
+}
+
+// WSContentPayload is the complete answer. It comes once, before done.
+type WSContentPayload struct {
+	// Source is "directory" or "previous_answer" when the answer was not
+	// searched for.
+	Source        string             `json:"source,omitempty"`
+	Text          string             `json:"text"`
+	Citations     []string           `json:"citations"`
+	Location      []Location         `json:"location"`
+	ImagesURL     []string           `json:"images_url"`
+	Organizations []OrgInfo          `json:"organizations"`
+	Documents     []DocumentCitation `json:"documents"`
+}
+
+// WSOrganizationPayload is an organization of a list answer, sent as soon
+// as it is found.
+type WSOrganizationPayload struct {
+	Organization OrgInfo `json:"organization"`
+}
+
+// WSCitationPayload is a knowledge base document the answer is grounded in.
+type WSCitationPayload struct {
+	Citation DocumentCitation `json:"citation"`
+}
+
+// Location is a point on the map.
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// WSWarningPayload tells why a question was not answered, e.g. a reached
+// limit. The session stays open.
+type WSWarningPayload struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// WSErrorPayload reports a failed answer. Retryable errors are worth
+// asking again.
+type WSErrorPayload struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+}
+
+// WSDonePayload ends an answer.
+type WSDonePayload struct{}
+
+// WSCancelledPayload ends an answer the client cancelled, in place of
+// content and done.
+type WSCancelledPayload struct{}
@@ -43,8 +43,55 @@ type (
 		CreateChatRoom(ctx context.Context, req *entity.ChatRoomCreate) (string, error)
 		GetChatRoomByUserId(ctx context.Context, id *entity.GetChatRoomReq) (*entity.ChatRoomList, error)
 		GetChatRoomChat(ctx context.Context, id *entity.ById, limit, offset int) (*entity.ChatList, error)
-		Check(ctx context.Context, user_id, chatRoomID string) (int, error) 
+		GetChatRoomOwner(ctx context.Context, chatRoomID string) (string, error)
+		GetAnswer(ctx context.Context, chatRoomID, messageID string) (*entity.ContentRes, error)
+		Check(ctx context.Context, user_id, chatRoomID, language string) (int, error)
 		DeleteChatRoom(ctx context.Context, id *entity.ById) error
+		GetAnswersSince(ctx context.Context, since time.Time, limit int) ([]entity.Snippet, error)
+		GetRecentTurns(ctx context.Context, chatRoomID string, limit int) ([]entity.Turn, error)
+		GetTurns(ctx context.Context, chatRoomID string, offset, limit int) ([]entity.Turn, error)
+		GetSummary(ctx context.Context, chatRoomID string) (*entity.ChatSummary, error)
+		GetRoomsToSummarize(ctx context.Context, keep, minNew, limit int) ([]entity.ChatSummary, error)
+		SaveSummary(ctx context.Context, chatRoomID, summary string, exchanges int) error
+	}
+
+	// PromptRepo -.
+	PromptRepoI interface {
+		Create(ctx context.Context, req *entity.CreatePrompt) (*entity.Prompt, error)
+		GetByVersion(ctx context.Context, name string, version int) (*entity.Prompt, error)
+		GetAll(ctx context.Context, name string, filter *entity.Filter) (*entity.PromptList, error)
+		GetActive(ctx context.Context) ([]entity.Prompt, error)
+		Activate(ctx context.Context, name string, version int) error
+	}
+
+	// OrganizationRepo -.
+	OrganizationRepoI interface {
+		Upsert(ctx context.Context, req *entity.Organization) (string, error)
+		GetById(ctx context.Context, id string) (*entity.Organization, error)
+		Search(ctx context.Context, req *entity.OrganizationSearch) (*entity.OrgInfoList, error)
+		FindByNameWords(ctx context.Context, words []string, freshSince time.Time) ([]entity.Organization, error)
+		GetSnippetsSince(ctx context.Context, since time.Time, limit int) ([]entity.Snippet, error)
+		GetMergeCandidates(ctx context.Context, status string, filter *entity.Filter) (*entity.MergeCandidateList, error)
+		ConfirmMerge(ctx context.Context, id, userID string) error
+		RejectMerge(ctx context.Context, id, userID string) error
+		GetStale(ctx context.Context, checkedBefore time.Time, limit int) ([]entity.Organization, error)
+		Reverify(ctx context.Context, req *entity.Organization, revisions []entity.OrganizationRevision) error
+		MarkChecked(ctx context.Context, id string) error
+		GetRevisions(ctx context.Context, organizationID string, filter *entity.Filter) (*entity.OrganizationRevisionList, error)
+	}
+
+	// KBRepo -.
+	KBRepoI interface {
+		Create(ctx context.Context, doc *entity.KBDocument, chunks []string) (*entity.KBDocument, error)
+		GetAll(ctx context.Context, tag string, filter *entity.Filter) (*entity.KBDocumentList, error)
+		Delete(ctx context.Context, id string) error
+		GetSnippetsSince(ctx context.Context, since time.Time, limit int) ([]entity.Snippet, error)
+	}
+
+	// GeoCacheRepo -.
+	GeoCacheRepoI interface {
+		Get(ctx context.Context, key string) (*entity.GeoCache, error)
+		Upsert(ctx context.Context, req *entity.GeoCache) error
 	}
 
 	// DashboardRepo -.
@@ -12,6 +12,11 @@ type UseCase struct {
 	ChatRepo        ChatRepoI
 	PDFRepo         PDFRepoI
 	DashboardRepo   DashboardRepoI
+	PromptRepo      PromptRepoI
+	GeoCacheRepo    GeoCacheRepoI
+
+	OrganizationRepo OrganizationRepoI
+	KBRepo           KBRepoI
 }
 
 func New(pg *postgres.Postgres, config *config.Config) *UseCase {
@@ -20,5 +25,10 @@ func New(pg *postgres.Postgres, config *config.Config) *UseCase {
 		RestrictionRepo: repo.NewRestrictionRepo(pg, config),
 		ChatRepo:        repo.NewChatRepo(pg, config),
 		DashboardRepo:   repo.NewDashboardRepo(pg, config),
+		PromptRepo:      repo.NewPromptRepo(pg, config),
+		GeoCacheRepo:    repo.NewGeoCacheRepo(pg, config),
+
+		OrganizationRepo: repo.NewOrganizationRepo(pg, config),
+		KBRepo:           repo.NewKBRepo(pg, config),
 	}
 }
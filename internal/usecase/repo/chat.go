@@ -10,8 +10,10 @@ import (
 
 	"chatbot/config"
 	"chatbot/internal/entity"
+	"chatbot/pkg/lang"
 	"chatbot/pkg/postgres"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/lib/pq"
 )
 
@@ -41,11 +43,20 @@ func (r *ChatRepo) CreateChatRoom(ctx context.Context, req *entity.ChatRoomCreat
 func (r *ChatRepo) Create(ctx context.Context, req *entity.ChatCreate) error {
 	query := `
 		INSERT INTO chat (
-			chat_room_id, user_request, gemini_request, responce, citation_urls, location, images_url, organizations
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			chat_room_id, user_request, gemini_request, responce, citation_urls, location, images_url, organizations, documents, prompt_versions, interrupted, message_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, '')::uuid)
 		RETURNING id;
 	`
 
+	promptVersions := req.PromptVersions
+	if promptVersions == nil {
+		promptVersions = map[string]int{}
+	}
+	documents := req.Documents
+	if documents == nil {
+		documents = []entity.DocumentCitation{}
+	}
+
 	var id string
 	err := r.pg.Pool.QueryRow(ctx, query,
 		req.ChatRoomID,
@@ -56,6 +67,10 @@ func (r *ChatRepo) Create(ctx context.Context, req *entity.ChatCreate) error {
 		req.Location,
 		req.ImagesURL,
 		req.Organizations,
+		documents,
+		promptVersions,
+		req.Interrupted,
+		req.MessageID,
 	).Scan(&id)
 	if err != nil {
 		return err
@@ -118,6 +133,8 @@ func (r *ChatRepo) GetChatRoomChat(ctx context.Context, id *entity.ById, limit,
 	       location,
 	       images_url,
 	       organizations,
+	       documents,
+	       interrupted,
 	       created_at
 	FROM chat
 	WHERE chat_room_id = $1 AND deleted_at = 0
@@ -138,18 +155,20 @@ func (r *ChatRepo) GetChatRoomChat(ctx context.Context, id *entity.ById, limit,
 	var result entity.ChatList
 	for rows.Next() {
 		var (
-			id         string
-			chatRoomID string
-			userReq    string
-			response   string
-			citations  []string
-			locRaw     pq.StringArray
-			images     []string
-			orgs       []byte
-			createdAt  time.Time
+			id          string
+			chatRoomID  string
+			userReq     string
+			response    string
+			citations   []string
+			locRaw      pq.StringArray
+			images      []string
+			orgs        []byte
+			documents   []entity.DocumentCitation
+			interrupted bool
+			createdAt   time.Time
 		)
 
-		err := rows.Scan(&total_count, &id, &chatRoomID, &userReq, &response, &citations, &locRaw, &images, &orgs, &createdAt)
+		err := rows.Scan(&total_count, &id, &chatRoomID, &userReq, &response, &citations, &locRaw, &images, &orgs, &documents, &interrupted, &createdAt)
 		if err != nil {
 			return nil, err
 		}
@@ -186,6 +205,8 @@ func (r *ChatRepo) GetChatRoomChat(ctx context.Context, id *entity.ById, limit,
 				Location:      locParsed,
 				ImagesURL:     images,
 				Organizations: json.RawMessage(orgs),
+				Documents:     documents,
+				Interrupted:   interrupted,
 			},
 			CreatedAt: createdStr,
 		})
@@ -203,13 +224,215 @@ func (r *ChatRepo) GetChatRoomChat(ctx context.Context, id *entity.ById, limit,
 	return &result, nil
 }
 
-func (r *ChatRepo) Check(ctx context.Context, userID, chatRoomID string) (int, error) {
+// GetAnswersSince returns the Sonar and directory answers updated at or
+// after since, oldest first, for the semantic index.
+func (r *ChatRepo) GetAnswersSince(ctx context.Context, since time.Time, limit int) ([]entity.Snippet, error) {
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT c.id, c.gemini_request, c.responce, COALESCE(c.citation_urls, '{}'),
+			c.deleted_at <> 0 OR cr.deleted_at <> 0, c.interrupted, c.updated_at
+		FROM chat c
+		JOIN chat_rooms cr ON cr.id = c.chat_room_id
+		WHERE c.updated_at >= $1 AND c.gemini_request <> '' AND c.responce <> ''
+		ORDER BY c.updated_at
+		LIMIT $2`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []entity.Snippet
+	for rows.Next() {
+		s := entity.Snippet{Kind: entity.SnippetAnswer}
+		var interrupted bool
+		if err := rows.Scan(&s.ID, &s.Title, &s.Text, &s.Sources, &s.Deleted, &interrupted, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		s.Vetted = vettedAnswer(s.Sources, interrupted)
+		res = append(res, s)
+	}
+
+	return res, rows.Err()
+}
+
+// vettedAnswer reports whether a saved answer may be reused as is: it was
+// answered to the end and is backed by citations.
+func vettedAnswer(sources []string, interrupted bool) bool {
+	return len(sources) > 0 && !interrupted
+}
+
+// GetRecentTurns returns the last limit exchanges of a chat room as user
+// and assistant turns, oldest first.
+func (r *ChatRepo) GetRecentTurns(ctx context.Context, chatRoomID string, limit int) ([]entity.Turn, error) {
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT * FROM (
+			SELECT `+turnColumns+`
+			FROM chat
+			WHERE chat_room_id = $1 AND deleted_at = 0
+			ORDER BY created_at DESC
+			LIMIT $2
+		) t
+		ORDER BY created_at`, chatRoomID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTurns(rows)
+}
+
+// GetTurns returns limit exchanges of a chat room starting at the given
+// offset from the oldest, as user and assistant turns.
+func (r *ChatRepo) GetTurns(ctx context.Context, chatRoomID string, offset, limit int) ([]entity.Turn, error) {
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT `+turnColumns+`
+		FROM chat
+		WHERE chat_room_id = $1 AND deleted_at = 0
+		ORDER BY created_at
+		OFFSET $2
+		LIMIT $3`, chatRoomID, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTurns(rows)
+}
+
+func (r *ChatRepo) GetSummary(ctx context.Context, chatRoomID string) (*entity.ChatSummary, error) {
+	res := entity.ChatSummary{ChatRoomID: chatRoomID}
+	err := r.pg.Pool.QueryRow(ctx, `
+		SELECT summary, summary_exchanges
+		FROM chat_rooms
+		WHERE id = $1 AND deleted_at = 0`, chatRoomID).Scan(&res.Text, &res.Exchanges)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetRoomsToSummarize returns up to limit chat rooms with at least minNew
+// exchanges beyond their summary and the keep most recent ones, most
+// recently active first.
+func (r *ChatRepo) GetRoomsToSummarize(ctx context.Context, keep, minNew, limit int) ([]entity.ChatSummary, error) {
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT cr.id, cr.summary, cr.summary_exchanges, COUNT(c.id)
+		FROM chat_rooms cr
+		JOIN chat c ON c.chat_room_id = cr.id AND c.deleted_at = 0
+		WHERE cr.deleted_at = 0
+		GROUP BY cr.id
+		HAVING COUNT(c.id) - cr.summary_exchanges - $1 >= $2
+		ORDER BY MAX(c.created_at) DESC
+		LIMIT $3`, keep, minNew, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []entity.ChatSummary
+	for rows.Next() {
+		var s entity.ChatSummary
+		if err := rows.Scan(&s.ChatRoomID, &s.Text, &s.Exchanges, &s.Total); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+
+	return res, rows.Err()
+}
+
+// SaveSummary stores a summary covering the given number of exchanges. A
+// summary that covers no more than the stored one is ignored.
+func (r *ChatRepo) SaveSummary(ctx context.Context, chatRoomID, summary string, exchanges int) error {
+	_, err := r.pg.Pool.Exec(ctx, `
+		UPDATE chat_rooms
+		SET summary = $2, summary_exchanges = $3, summarized_at = NOW()
+		WHERE id = $1 AND summary_exchanges < $3`, chatRoomID, summary, exchanges)
+	return err
+}
+
+// turnColumns are read by scanTurns. List answers have no text, their turn
+// names the organizations instead.
+const turnColumns = `
+	user_request, responce, COALESCE((
+		SELECT string_agg(o->>'name', ', ')
+		FROM jsonb_array_elements(CASE WHEN jsonb_typeof(organizations) = 'array' THEN organizations ELSE '[]'::jsonb END) o
+	), '') AS organization_names, created_at`
+
+func scanTurns(rows pgx.Rows) ([]entity.Turn, error) {
+	var res []entity.Turn
+	for rows.Next() {
+		var request, response, orgNames string
+		var createdAt time.Time
+		if err := rows.Scan(&request, &response, &orgNames, &createdAt); err != nil {
+			return nil, err
+		}
+		if response == "" && orgNames != "" {
+			response = "Organizations: " + orgNames
+		}
+
+		if request != "" {
+			res = append(res, entity.Turn{Role: entity.RoleUser, Text: request, CreatedAt: createdAt})
+		}
+		if response != "" {
+			res = append(res, entity.Turn{Role: entity.RoleAssistant, Text: response, CreatedAt: createdAt})
+		}
+	}
+
+	return res, rows.Err()
+}
+
+func (r *ChatRepo) GetChatRoomOwner(ctx context.Context, chatRoomID string) (string, error) {
+	query := `SELECT user_id FROM chat_rooms WHERE id = $1 AND deleted_at = 0`
+
+	var userID string
+	err := r.pg.Pool.QueryRow(ctx, query, chatRoomID).Scan(&userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get chat room owner: %w", err)
+	}
+	return userID, nil
+}
+
+// GetAnswer returns the stored answer streamed under a WebSocket message ID
+// in the chat room.
+func (r *ChatRepo) GetAnswer(ctx context.Context, chatRoomID, messageID string) (*entity.ContentRes, error) {
+	query := `
+		SELECT responce, citation_urls, location, images_url, organizations, documents, interrupted
+		FROM chat
+		WHERE chat_room_id = $1 AND message_id = $2 AND deleted_at = 0
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	var (
+		res    entity.ContentRes
+		locRaw pq.StringArray
+		orgs   []byte
+	)
+	err := r.pg.Pool.QueryRow(ctx, query, chatRoomID, messageID).Scan(
+		&res.Text, &res.Citations, &locRaw, &res.ImagesURL, &orgs, &res.Documents, &res.Interrupted,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answer: %w", err)
+	}
+
+	for _, l := range locRaw {
+		var obj map[string]float64
+		if err := json.Unmarshal([]byte(l), &obj); err == nil {
+			res.Location = append(res.Location, obj)
+		}
+	}
+	res.Organizations = json.RawMessage(orgs)
+
+	return &res, nil
+}
+
+func (r *ChatRepo) Check(ctx context.Context, userID, chatRoomID, language string) (int, error) {
 
 	if userID == "" {
-		query := `SELECT user_id FROM chat_rooms WHERE id = $1 AND deleted_at = 0`
-		err := r.pg.Pool.QueryRow(ctx, query, chatRoomID).Scan(&userID)
+		var err error
+		userID, err = r.GetChatRoomOwner(ctx, chatRoomID)
 		if err != nil {
-			return 0, fmt.Errorf("failed to get chat room owner: %w", err)
+			return 0, err
 		}
 	}
 
@@ -260,9 +483,9 @@ func (r *ChatRepo) Check(ctx context.Context, userID, chatRoomID string) (int, e
 
 	if remaining <= 0 {
 		if role == "guest" {
-			return 0, errors.New("sizning 3 ta bepul so‘rovingiz tugadi, davom etish uchun ro‘yxatdan o‘ting")
+			return 0, lang.NewError(language, lang.GuestLimit)
 		}
-		return 0, errors.New("kunlik limit tugadi")
+		return 0, lang.NewError(language, lang.DailyLimit)
 	}
 
 	fmt.Println("id ", userID, "chatRoomID ", chatRoomID, "role ", role, "requestLimit ", requestLimit, "requestCount ", requestCount, "remaining ", remaining)
@@ -270,11 +493,28 @@ func (r *ChatRepo) Check(ctx context.Context, userID, chatRoomID string) (int, e
 	return remaining, nil
 }
 
+// DeleteChatRoom deletes a chat room and its messages. The messages are
+// touched so the semantic index sync sees them and drops the answers.
 func (r *ChatRepo) DeleteChatRoom(ctx context.Context, id *entity.ById) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `UPDATE chat_rooms SET deleted_at = EXTRACT(EPOCH FROM NOW())::bigint WHERE id = $1`
-	_, err := r.pg.Pool.Exec(ctx, query, id.Id)
+	_, err = tx.Exec(ctx, query, id.Id)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	_, err = tx.Exec(ctx, `
+		UPDATE chat
+		SET deleted_at = EXTRACT(EPOCH FROM NOW())::bigint, updated_at = NOW()
+		WHERE chat_room_id = $1 AND deleted_at = 0`, id.Id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
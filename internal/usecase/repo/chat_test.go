@@ -0,0 +1,25 @@
+package repo
+
+import "testing"
+
+func TestVettedAnswer(t *testing.T) {
+	tests := []struct {
+		name        string
+		sources     []string
+		interrupted bool
+		want        bool
+	}{
+		{name: "complete with citations", sources: []string{"https://hamkorbank.uz"}, want: true},
+		{name: "without citations", want: false},
+		{name: "interrupted with citations", sources: []string{"https://hamkorbank.uz"}, interrupted: true, want: false},
+		{name: "interrupted without citations", interrupted: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vettedAnswer(tt.sources, tt.interrupted); got != tt.want {
+				t.Errorf("vettedAnswer(%v, %v) = %v, want %v", tt.sources, tt.interrupted, got, tt.want)
+			}
+		})
+	}
+}
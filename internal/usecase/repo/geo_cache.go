@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"chatbot/config"
+	"chatbot/internal/entity"
+	"chatbot/pkg/postgres"
+
+	"github.com/jackc/pgx/v4"
+)
+
+type GeoCacheRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+}
+
+func NewGeoCacheRepo(pg *postgres.Postgres, config *config.Config) *GeoCacheRepo {
+	return &GeoCacheRepo{
+		pg:     pg,
+		config: config,
+	}
+}
+
+// Get returns the entry for key, or nil if there is none or it expired.
+func (r *GeoCacheRepo) Get(ctx context.Context, key string) (*entity.GeoCache, error) {
+	query := `
+		SELECT key, url, latitude, longitude, source, failed, resolved_at, expires_at
+		FROM geo_cache
+		WHERE key = $1 AND expires_at > NOW()`
+
+	var res entity.GeoCache
+	var lat, lng sql.NullFloat64
+	err := r.pg.Pool.QueryRow(ctx, query, key).Scan(
+		&res.Key,
+		&res.URL,
+		&lat,
+		&lng,
+		&res.Source,
+		&res.Failed,
+		&res.ResolvedAt,
+		&res.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	res.Latitude = lat.Float64
+	res.Longitude = lng.Float64
+
+	return &res, nil
+}
+
+func (r *GeoCacheRepo) Upsert(ctx context.Context, req *entity.GeoCache) error {
+	query := `
+		INSERT INTO geo_cache (key, url, latitude, longitude, source, failed, resolved_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (key) DO UPDATE SET
+			url = EXCLUDED.url,
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
+			source = EXCLUDED.source,
+			failed = EXCLUDED.failed,
+			resolved_at = EXCLUDED.resolved_at,
+			expires_at = EXCLUDED.expires_at`
+
+	var lat, lng sql.NullFloat64
+	if !req.Failed {
+		lat = sql.NullFloat64{Float64: req.Latitude, Valid: true}
+		lng = sql.NullFloat64{Float64: req.Longitude, Valid: true}
+	}
+
+	_, err := r.pg.Pool.Exec(ctx, query,
+		req.Key,
+		req.URL,
+		lat,
+		lng,
+		req.Source,
+		req.Failed,
+		req.ResolvedAt,
+		req.ExpiresAt,
+	)
+
+	return err
+}
@@ -0,0 +1,182 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"chatbot/config"
+	"chatbot/internal/entity"
+	"chatbot/pkg/postgres"
+)
+
+type KBRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+}
+
+func NewKBRepo(pg *postgres.Postgres, config *config.Config) *KBRepo {
+	return &KBRepo{
+		pg:     pg,
+		config: config,
+	}
+}
+
+// Create stores a document with its chunks and returns it with the ID.
+func (r *KBRepo) Create(ctx context.Context, doc *entity.KBDocument, chunks []string) (*entity.KBDocument, error) {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tags := doc.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	var createdAt time.Time
+	err = tx.QueryRow(ctx, `
+		INSERT INTO kb_documents (title, file_name, url, content_type, tags, chunk_count, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::uuid)
+		RETURNING id, created_at`,
+		doc.Title, doc.FileName, doc.URL, doc.ContentType, tags, len(chunks), doc.UploadedBy,
+	).Scan(&doc.ID, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, text := range chunks {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO kb_chunks (document_id, position, text) VALUES ($1, $2, $3)`,
+			doc.ID, i, text)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	doc.Tags = tags
+	doc.ChunkCount = len(chunks)
+	doc.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+	doc.UpdatedAt = doc.CreatedAt
+	return doc, nil
+}
+
+func (r *KBRepo) GetAll(ctx context.Context, tag string, filter *entity.Filter) (*entity.KBDocumentList, error) {
+	query := `
+		SELECT COUNT(id) OVER () AS total_count, id, title, file_name, url, content_type, tags,
+			chunk_count, COALESCE(uploaded_by::text, ''), created_at, updated_at
+		FROM kb_documents
+		WHERE deleted_at = 0`
+
+	var args []interface{}
+	if tag != "" {
+		query += " AND $1 = ANY(tags)"
+		args = append(args, tag)
+	}
+	query += " ORDER BY created_at DESC"
+
+	if filter.Limit != 0 {
+		query += " LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result entity.KBDocumentList
+	for rows.Next() {
+		var d entity.KBDocument
+		var createdAt, updatedAt time.Time
+		var count int
+		err := rows.Scan(
+			&count,
+			&d.ID,
+			&d.Title,
+			&d.FileName,
+			&d.URL,
+			&d.ContentType,
+			&d.Tags,
+			&d.ChunkCount,
+			&d.UploadedBy,
+			&createdAt,
+			&updatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		d.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		d.UpdatedAt = updatedAt.Format("2006-01-02 15:04:05")
+		result.Documents = append(result.Documents, d)
+		result.Count = count
+	}
+
+	return &result, rows.Err()
+}
+
+// Delete soft-deletes a document. Its chunks are touched so the semantic
+// index drops them on the next sync.
+func (r *KBRepo) Delete(ctx context.Context, id string) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	res, err := tx.Exec(ctx, `
+		UPDATE kb_documents
+		SET deleted_at = EXTRACT(EPOCH FROM NOW()), updated_at = NOW()
+		WHERE id = $1 AND deleted_at = 0`, id)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return errors.New("document not found")
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE kb_chunks SET updated_at = NOW() WHERE document_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetSnippetsSince returns the document chunks updated at or after since,
+// oldest first, for the semantic index. Chunks of deleted documents are
+// included so the index can drop them.
+func (r *KBRepo) GetSnippetsSince(ctx context.Context, since time.Time, limit int) ([]entity.Snippet, error) {
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT c.id, d.id, d.title, c.text, d.url, d.tags, d.deleted_at <> 0, c.updated_at
+		FROM kb_chunks c
+		JOIN kb_documents d ON d.id = c.document_id
+		WHERE c.updated_at >= $1
+		ORDER BY c.updated_at
+		LIMIT $2`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []entity.Snippet
+	for rows.Next() {
+		s := entity.Snippet{Kind: entity.SnippetDocument, Vetted: true}
+		var url string
+		err := rows.Scan(&s.ID, &s.ParentID, &s.Title, &s.Text, &url, &s.Tags, &s.Deleted, &s.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		s.Sources = []string{url}
+		res = append(res, s)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,741 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"chatbot/config"
+	"chatbot/internal/entity"
+	"chatbot/pkg/directory"
+	"chatbot/pkg/postgres"
+
+	"github.com/jackc/pgx/v4"
+)
+
+type OrganizationRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+}
+
+func NewOrganizationRepo(pg *postgres.Postgres, config *config.Config) *OrganizationRepo {
+	return &OrganizationRepo{
+		pg:     pg,
+		config: config,
+	}
+}
+
+const organizationColumns = `
+	id, name, description, industry, founded_year, address, city, country,
+	phone, email, website, social_media, tax_id, registration_number, latitude, longitude,
+	sources, images_url, field_sources, created_at, updated_at, verified_at`
+
+// Upsert matches req against the directory with directory.Compare. A match
+// of directory.AutoMergeConfidence or more is merged into; otherwise a new
+// organization is created and weaker matches are stored as merge candidates
+// for review. Empty fields never overwrite known values, sources and images
+// accumulate.
+func (r *OrganizationRepo) Upsert(ctx context.Context, req *entity.Organization) (string, error) {
+	normalized := directory.NormalizeName(req.Name)
+	if normalized == "" {
+		return "", errors.New("organization name is empty")
+	}
+	nameKey := directory.NameKey(req.Name)
+	phoneKey := directory.NormalizePhone(req.Phone)
+	websiteKey := directory.NormalizeWebsite(req.Website)
+
+	firstWord := nameKey
+	if i := strings.IndexByte(nameKey, ' '); i > 0 {
+		firstWord = nameKey[:i]
+	}
+
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT `+organizationColumns+`
+		FROM organizations
+		WHERE deleted_at = 0 AND (
+			($1 <> '' AND tax_id = $1) OR
+			($2 <> '' AND phone_key = $2) OR
+			($3 <> '' AND website_key = $3) OR
+			name_key = $4 OR
+			split_part(name_key, ' ', 1) = $5)
+		ORDER BY updated_at DESC
+		LIMIT 50
+		FOR UPDATE`, req.TaxID, phoneKey, websiteKey, nameKey, firstWord)
+	if err != nil {
+		return "", err
+	}
+
+	type scored struct {
+		id    string
+		match directory.Match
+	}
+	var candidates []scored
+	var best scored
+	for rows.Next() {
+		existing, err := scanOrganization(rows)
+		if err != nil {
+			rows.Close()
+			return "", err
+		}
+
+		m := directory.Compare(*existing, *req)
+		if m.Confidence < directory.CandidateConfidence {
+			continue
+		}
+		candidates = append(candidates, scored{id: existing.ID, match: m})
+		if m.Confidence > best.match.Confidence {
+			best = scored{id: existing.ID, match: m}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	var lat, lng sql.NullFloat64
+	if req.Location.Latitude != 0 || req.Location.Longitude != 0 {
+		lat = sql.NullFloat64{Float64: req.Location.Latitude, Valid: true}
+		lng = sql.NullFloat64{Float64: req.Location.Longitude, Valid: true}
+	}
+
+	socialMedia := req.SocialMedia
+	if socialMedia == nil {
+		socialMedia = map[string]string{}
+	}
+	sources := nonEmpty(req.Sources)
+	images := nonEmpty(req.ImagesURL)
+	fieldSources := directory.Observe(*req, directory.SourceURL(*req), time.Now().UTC())
+
+	if best.match.Confidence >= directory.AutoMergeConfidence {
+		_, err = tx.Exec(ctx, `
+			UPDATE organizations SET
+				description = COALESCE(NULLIF($2, ''), description),
+				industry = COALESCE(NULLIF($3, ''), industry),
+				founded_year = COALESCE(NULLIF($4, 0), founded_year),
+				address = COALESCE(NULLIF($5, ''), address),
+				city = COALESCE(NULLIF($6, ''), city),
+				country = COALESCE(NULLIF($7, ''), country),
+				phone = COALESCE(NULLIF($8, ''), phone),
+				email = COALESCE(NULLIF($9, ''), email),
+				website = COALESCE(NULLIF($10, ''), website),
+				social_media = social_media || $11::jsonb,
+				tax_id = COALESCE(NULLIF($12, ''), tax_id),
+				registration_number = COALESCE(NULLIF($13, ''), registration_number),
+				latitude = COALESCE($14, latitude),
+				longitude = COALESCE($15, longitude),
+				sources = ARRAY(SELECT DISTINCT unnest(sources || $16::text[])),
+				images_url = ARRAY(SELECT DISTINCT unnest(images_url || $17::text[])),
+				phone_key = COALESCE(NULLIF($18, ''), phone_key),
+				website_key = COALESCE(NULLIF($19, ''), website_key),
+				field_sources = field_sources || $20::jsonb,
+				updated_at = NOW()
+			WHERE id = $1`,
+			best.id, req.Description, req.Industry, req.FoundedYear, req.Address, req.City, req.Country,
+			req.Phone, req.Email, req.Website, socialMedia, req.TaxID, req.RegistrationNumber, lat, lng,
+			sources, images, phoneKey, websiteKey, fieldSources,
+		)
+		if err != nil {
+			return "", err
+		}
+
+		return best.id, tx.Commit(ctx)
+	}
+
+	var id string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO organizations (
+			name, normalized_name, description, industry, founded_year, address, city, country,
+			phone, email, website, social_media, tax_id, registration_number, latitude, longitude,
+			sources, images_url, name_key, phone_key, website_key, field_sources
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		RETURNING id`,
+		req.Name, normalized, req.Description, req.Industry, req.FoundedYear, req.Address, req.City, req.Country,
+		req.Phone, req.Email, req.Website, socialMedia, req.TaxID, req.RegistrationNumber, lat, lng,
+		sources, images, nameKey, phoneKey, websiteKey, fieldSources,
+	).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range candidates {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO organization_merge_candidates (organization_id, duplicate_id, confidence, reasons)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (organization_id, duplicate_id) DO NOTHING`,
+			c.id, id, c.match.Confidence, nonEmpty(c.match.Reasons),
+		)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+func (r *OrganizationRepo) GetById(ctx context.Context, id string) (*entity.Organization, error) {
+	query := `
+		SELECT ` + organizationColumns + `
+		FROM organizations
+		WHERE id = $1 AND deleted_at = 0`
+
+	res, err := scanOrganization(r.pg.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// FindByNameWords returns the organizations updated or confirmed by
+// re-verification since the given time whose name key starts with one of
+// words.
+func (r *OrganizationRepo) FindByNameWords(ctx context.Context, words []string, freshSince time.Time) ([]entity.Organization, error) {
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT `+organizationColumns+`
+		FROM organizations
+		WHERE deleted_at = 0 AND split_part(name_key, ' ', 1) = ANY($1) AND GREATEST(updated_at, verified_at) >= $2
+		ORDER BY GREATEST(updated_at, verified_at) DESC
+		LIMIT 20`, words, freshSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []entity.Organization
+	for rows.Next() {
+		o, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *o)
+	}
+
+	return res, rows.Err()
+}
+
+// GetSnippetsSince returns the organizations updated at or after since,
+// oldest first, for the semantic index. Deleted ones are included so the
+// index can drop them.
+func (r *OrganizationRepo) GetSnippetsSince(ctx context.Context, since time.Time, limit int) ([]entity.Snippet, error) {
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT id, name, description, industry, address, city, sources, deleted_at <> 0, updated_at
+		FROM organizations
+		WHERE updated_at >= $1
+		ORDER BY updated_at
+		LIMIT $2`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []entity.Snippet
+	for rows.Next() {
+		s := entity.Snippet{Kind: entity.SnippetOrganization, Vetted: true}
+		var description, industry, address, city string
+		err := rows.Scan(&s.ID, &s.Title, &description, &industry, &address, &city, &s.Sources, &s.Deleted, &s.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		s.Text = strings.Join(nonEmpty([]string{description, industry, address, city}), ". ")
+		res = append(res, s)
+	}
+
+	return res, rows.Err()
+}
+
+// Search filters the directory by full-text query, industry, city and
+// distance. Results are ordered by distance when near is given, else by
+// rank when a query is given, else by name.
+func (r *OrganizationRepo) Search(ctx context.Context, req *entity.OrganizationSearch) (*entity.OrgInfoList, error) {
+	var (
+		args  []interface{}
+		where = []string{"deleted_at = 0"}
+		order = "name"
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	distance := "0::float8"
+	if req.Near {
+		lat, lng := arg(req.Latitude), arg(req.Longitude)
+		distance = `6371000 * 2 * asin(sqrt(
+			power(sin(radians(latitude - ` + lat + `) / 2), 2) +
+			cos(radians(` + lat + `)) * cos(radians(latitude)) * power(sin(radians(longitude - ` + lng + `) / 2), 2)))`
+
+		// A bounding box first, so the index narrows the rows the distance
+		// is computed for.
+		dLat := req.Radius / 111320
+		dLng := req.Radius / (111320 * math.Max(math.Cos(req.Latitude*math.Pi/180), 0.01))
+		where = append(where,
+			"latitude IS NOT NULL",
+			"latitude BETWEEN "+arg(req.Latitude-dLat)+" AND "+arg(req.Latitude+dLat),
+			"longitude BETWEEN "+arg(req.Longitude-dLng)+" AND "+arg(req.Longitude+dLng),
+			distance+" <= "+arg(req.Radius),
+		)
+		order = "distance"
+	}
+
+	if req.Query != "" {
+		q := arg(req.Query)
+		where = append(where, "(search @@ websearch_to_tsquery('simple', "+q+") OR name ILIKE "+arg("%"+likeEscaper.Replace(req.Query)+"%")+` ESCAPE '\')`)
+		if !req.Near {
+			order = "ts_rank(search, websearch_to_tsquery('simple', " + q + ")) DESC, name"
+		}
+	}
+	if req.Industry != "" {
+		where = append(where, "lower(industry) = lower("+arg(req.Industry)+")")
+	}
+	if req.City != "" {
+		where = append(where, "lower(city) = lower("+arg(req.City)+")")
+	}
+
+	query := `
+		SELECT COUNT(id) OVER () AS total_count, id, name, address, latitude, longitude,
+			phone, email, description, website, sources, images_url, ` + distance + ` AS distance
+		FROM organizations
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY ` + order
+
+	if req.Limit != 0 {
+		query += " LIMIT " + arg(req.Limit) + " OFFSET " + arg(req.Offset)
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := entity.OrgInfoList{Organizations: []entity.OrgInfo{}}
+	for rows.Next() {
+		var o entity.OrgInfo
+		var lat, lng sql.NullFloat64
+		var dist float64
+		var count int
+		err := rows.Scan(
+			&count,
+			&o.ID,
+			&o.Name,
+			&o.Address,
+			&lat,
+			&lng,
+			&o.Phone,
+			&o.Email,
+			&o.Description,
+			&o.Website,
+			&o.Sources,
+			&o.ImagesURL,
+			&dist,
+		)
+		if err != nil {
+			return nil, err
+		}
+		o.Location.Latitude = lat.Float64
+		o.Location.Longitude = lng.Float64
+		result.Organizations = append(result.Organizations, o)
+		result.Count = count
+	}
+
+	return &result, rows.Err()
+}
+
+func (r *OrganizationRepo) GetMergeCandidates(ctx context.Context, status string, filter *entity.Filter) (*entity.MergeCandidateList, error) {
+	query := `
+		SELECT COUNT(m.id) OVER () AS total_count, m.id, o.id, o.name, d.id, d.name,
+			m.confidence, m.reasons, m.status, m.decided_by, m.created_at, m.decided_at
+		FROM organization_merge_candidates m
+		JOIN organizations o ON o.id = m.organization_id
+		JOIN organizations d ON d.id = m.duplicate_id`
+
+	var args []interface{}
+	if status != "" {
+		query += " WHERE m.status = $1"
+		args = append(args, status)
+	}
+	query += " ORDER BY m.confidence DESC, m.created_at DESC"
+
+	if filter.Limit != 0 {
+		query += " LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result entity.MergeCandidateList
+	for rows.Next() {
+		var m entity.MergeCandidate
+		var decidedBy sql.NullString
+		var createdAt time.Time
+		var decidedAt sql.NullTime
+		var count int
+		err := rows.Scan(
+			&count,
+			&m.ID,
+			&m.Organization.ID,
+			&m.Organization.Name,
+			&m.Duplicate.ID,
+			&m.Duplicate.Name,
+			&m.Confidence,
+			&m.Reasons,
+			&m.Status,
+			&decidedBy,
+			&createdAt,
+			&decidedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		m.DecidedBy = decidedBy.String
+		m.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		if decidedAt.Valid {
+			m.DecidedAt = decidedAt.Time.Format("2006-01-02 15:04:05")
+		}
+		result.Candidates = append(result.Candidates, m)
+		result.Count = count
+	}
+
+	return &result, rows.Err()
+}
+
+// ConfirmMerge folds the duplicate into the organization: the organization
+// keeps its values and takes the duplicate's where it has none, the
+// duplicate is soft-deleted and its other pending candidates become
+// obsolete.
+func (r *OrganizationRepo) ConfirmMerge(ctx context.Context, id, userID string) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	orgID, dupID, err := pendingMerge(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// The duplicate may hold the tax ID the organization takes over, so it leaves
+	// the unique index first.
+	_, err = tx.Exec(ctx, `
+		UPDATE organizations
+		SET deleted_at = EXTRACT(EPOCH FROM NOW()), merged_into = $2, updated_at = NOW()
+		WHERE id = $1`, dupID, orgID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE organizations o SET
+			description = COALESCE(NULLIF(o.description, ''), d.description),
+			industry = COALESCE(NULLIF(o.industry, ''), d.industry),
+			founded_year = COALESCE(NULLIF(o.founded_year, 0), d.founded_year),
+			address = COALESCE(NULLIF(o.address, ''), d.address),
+			city = COALESCE(NULLIF(o.city, ''), d.city),
+			country = COALESCE(NULLIF(o.country, ''), d.country),
+			phone = COALESCE(NULLIF(o.phone, ''), d.phone),
+			email = COALESCE(NULLIF(o.email, ''), d.email),
+			website = COALESCE(NULLIF(o.website, ''), d.website),
+			social_media = d.social_media || o.social_media,
+			tax_id = COALESCE(NULLIF(o.tax_id, ''), d.tax_id),
+			registration_number = COALESCE(NULLIF(o.registration_number, ''), d.registration_number),
+			latitude = COALESCE(o.latitude, d.latitude),
+			longitude = COALESCE(o.longitude, d.longitude),
+			sources = ARRAY(SELECT DISTINCT unnest(o.sources || d.sources)),
+			images_url = ARRAY(SELECT DISTINCT unnest(o.images_url || d.images_url)),
+			phone_key = COALESCE(NULLIF(o.phone_key, ''), d.phone_key),
+			website_key = COALESCE(NULLIF(o.website_key, ''), d.website_key),
+			field_sources = d.field_sources || o.field_sources,
+			updated_at = NOW()
+		FROM organizations d
+		WHERE o.id = $1 AND d.id = $2`, orgID, dupID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE organization_merge_candidates
+		SET status = 'obsolete', decided_at = NOW()
+		WHERE status = 'pending' AND id <> $1 AND (organization_id = $2 OR duplicate_id = $2)`, id, dupID)
+	if err != nil {
+		return err
+	}
+
+	if err := decideMerge(ctx, tx, id, "confirmed", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *OrganizationRepo) RejectMerge(ctx context.Context, id, userID string) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, _, err := pendingMerge(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if err := decideMerge(ctx, tx, id, "rejected", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func pendingMerge(ctx context.Context, tx pgx.Tx, id string) (string, string, error) {
+	var orgID, dupID, status string
+	err := tx.QueryRow(ctx, `
+		SELECT organization_id, duplicate_id, status
+		FROM organization_merge_candidates
+		WHERE id = $1
+		FOR UPDATE`, id).Scan(&orgID, &dupID, &status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", "", errors.New("merge candidate not found")
+		}
+		return "", "", err
+	}
+	if status != "pending" {
+		return "", "", errors.New("merge candidate is already " + status)
+	}
+
+	return orgID, dupID, nil
+}
+
+func decideMerge(ctx context.Context, tx pgx.Tx, id, status, userID string) error {
+	var decidedBy sql.NullString
+	if userID != "" {
+		decidedBy = sql.NullString{String: userID, Valid: true}
+	}
+
+	_, err := tx.Exec(ctx, `
+		UPDATE organization_merge_candidates
+		SET status = $2, decided_by = $3, decided_at = NOW()
+		WHERE id = $1`, id, status, decidedBy)
+	return err
+}
+
+// GetStale returns up to limit organizations last checked before the
+// given time, least recently checked first.
+func (r *OrganizationRepo) GetStale(ctx context.Context, checkedBefore time.Time, limit int) ([]entity.Organization, error) {
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT `+organizationColumns+`
+		FROM organizations
+		WHERE deleted_at = 0 AND checked_at < $1
+		ORDER BY checked_at
+		LIMIT $2`, checkedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []entity.Organization
+	for rows.Next() {
+		o, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *o)
+	}
+
+	return res, rows.Err()
+}
+
+// Reverify stores the result of re-verifying req: its verified fields, the
+// field sources and the revisions of the values that changed. The
+// organization is marked verified and checked either way.
+func (r *OrganizationRepo) Reverify(ctx context.Context, req *entity.Organization, revisions []entity.OrganizationRevision) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var lat, lng sql.NullFloat64
+	if req.Location.Latitude != 0 || req.Location.Longitude != 0 {
+		lat = sql.NullFloat64{Float64: req.Location.Latitude, Valid: true}
+		lng = sql.NullFloat64{Float64: req.Location.Longitude, Valid: true}
+	}
+
+	fieldSources := req.FieldSources
+	if fieldSources == nil {
+		fieldSources = map[string]entity.FieldSource{}
+	}
+
+	// Only a change is an update; a confirmation just moves verified_at.
+	_, err = tx.Exec(ctx, `
+		UPDATE organizations SET
+			address = $2,
+			phone = $3,
+			email = $4,
+			website = $5,
+			latitude = $6,
+			longitude = $7,
+			phone_key = $8,
+			website_key = $9,
+			field_sources = $10,
+			verified_at = NOW(),
+			checked_at = NOW(),
+			updated_at = CASE WHEN $11 THEN NOW() ELSE updated_at END
+		WHERE id = $1`,
+		req.ID, req.Address, req.Phone, req.Email, req.Website, lat, lng,
+		directory.NormalizePhone(req.Phone), directory.NormalizeWebsite(req.Website), fieldSources,
+		len(revisions) > 0,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, rev := range revisions {
+		observedAt, err := time.Parse("2006-01-02 15:04:05", rev.ObservedAt)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO organization_revisions (organization_id, field, old_value, new_value, source_url, observed_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			req.ID, rev.Field, rev.OldValue, rev.NewValue, rev.SourceURL, observedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// MarkChecked records a re-verification that could not confirm the
+// organization. Only checked_at moves, so the organization is not served as
+// fresh on its account.
+func (r *OrganizationRepo) MarkChecked(ctx context.Context, id string) error {
+	_, err := r.pg.Pool.Exec(ctx, `UPDATE organizations SET checked_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// GetRevisions returns the changes found by re-verification, newest first,
+// for one organization or for all of them.
+func (r *OrganizationRepo) GetRevisions(ctx context.Context, organizationID string, filter *entity.Filter) (*entity.OrganizationRevisionList, error) {
+	query := `
+		SELECT COUNT(v.id) OVER () AS total_count, v.id, o.id, o.name, v.field,
+			v.old_value, v.new_value, v.source_url, v.observed_at, v.created_at
+		FROM organization_revisions v
+		JOIN organizations o ON o.id = v.organization_id`
+
+	var args []interface{}
+	if organizationID != "" {
+		query += " WHERE v.organization_id = $1"
+		args = append(args, organizationID)
+	}
+	query += " ORDER BY v.created_at DESC"
+
+	if filter.Limit != 0 {
+		query += " LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result entity.OrganizationRevisionList
+	for rows.Next() {
+		var v entity.OrganizationRevision
+		var observedAt, createdAt time.Time
+		var count int
+		err := rows.Scan(
+			&count,
+			&v.ID,
+			&v.OrganizationID,
+			&v.Organization,
+			&v.Field,
+			&v.OldValue,
+			&v.NewValue,
+			&v.SourceURL,
+			&observedAt,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		v.ObservedAt = observedAt.Format("2006-01-02 15:04:05")
+		v.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		result.Revisions = append(result.Revisions, v)
+		result.Count = count
+	}
+
+	return &result, rows.Err()
+}
+
+func scanOrganization(row pgx.Row) (*entity.Organization, error) {
+	var res entity.Organization
+	var lat, lng sql.NullFloat64
+	var createdAt, updatedAt, verifiedAt time.Time
+	err := row.Scan(
+		&res.ID,
+		&res.Name,
+		&res.Description,
+		&res.Industry,
+		&res.FoundedYear,
+		&res.Address,
+		&res.City,
+		&res.Country,
+		&res.Phone,
+		&res.Email,
+		&res.Website,
+		&res.SocialMedia,
+		&res.TaxID,
+		&res.RegistrationNumber,
+		&lat,
+		&lng,
+		&res.Sources,
+		&res.ImagesURL,
+		&res.FieldSources,
+		&createdAt,
+		&updatedAt,
+		&verifiedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	res.Location.Latitude = lat.Float64
+	res.Location.Longitude = lng.Float64
+	res.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+	res.UpdatedAt = updatedAt.Format("2006-01-02 15:04:05")
+	res.VerifiedAt = verifiedAt.Format("2006-01-02 15:04:05")
+
+	return &res, nil
+}
+
+// likeEscaper makes user input match literally in a LIKE pattern with
+// ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func nonEmpty(values []string) []string {
+	res := []string{}
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
@@ -0,0 +1,159 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"chatbot/config"
+	"chatbot/internal/entity"
+	"chatbot/pkg/postgres"
+)
+
+type PromptRepo struct {
+	pg     *postgres.Postgres
+	config *config.Config
+}
+
+func NewPromptRepo(pg *postgres.Postgres, config *config.Config) *PromptRepo {
+	return &PromptRepo{
+		pg:     pg,
+		config: config,
+	}
+}
+
+func (r *PromptRepo) Create(ctx context.Context, req *entity.CreatePrompt) (*entity.Prompt, error) {
+	query := `
+		INSERT INTO prompts (name, version, body)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2
+		FROM prompts
+		WHERE name = $1
+		RETURNING id, version, created_at`
+
+	res := entity.Prompt{
+		Name: req.Name,
+		Body: req.Body,
+	}
+	var createdAt time.Time
+	err := r.pg.Pool.QueryRow(ctx, query, req.Name, req.Body).Scan(&res.ID, &res.Version, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	res.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+
+	return &res, nil
+}
+
+func (r *PromptRepo) GetByVersion(ctx context.Context, name string, version int) (*entity.Prompt, error) {
+	query := `
+		SELECT id, name, version, body, is_active, created_at
+		FROM prompts
+		WHERE name = $1 AND version = $2`
+
+	var res entity.Prompt
+	var createdAt time.Time
+	err := r.pg.Pool.QueryRow(ctx, query, name, version).Scan(
+		&res.ID,
+		&res.Name,
+		&res.Version,
+		&res.Body,
+		&res.IsActive,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	res.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+
+	return &res, nil
+}
+
+func (r *PromptRepo) GetAll(ctx context.Context, name string, filter *entity.Filter) (*entity.PromptList, error) {
+	query := `
+		SELECT COUNT(id) OVER () AS total_count, id, name, version, body, is_active, created_at
+		FROM prompts`
+
+	var args []interface{}
+	if name != "" {
+		query += " WHERE name = $1"
+		args = append(args, name)
+	}
+	query += " ORDER BY name, version DESC"
+
+	if filter.Limit != 0 {
+		query += " LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result entity.PromptList
+	for rows.Next() {
+		var p entity.Prompt
+		var createdAt time.Time
+		var count int
+		if err := rows.Scan(&count, &p.ID, &p.Name, &p.Version, &p.Body, &p.IsActive, &createdAt); err != nil {
+			return nil, err
+		}
+		p.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		result.Prompts = append(result.Prompts, p)
+		result.Count = count
+	}
+
+	return &result, nil
+}
+
+func (r *PromptRepo) GetActive(ctx context.Context) ([]entity.Prompt, error) {
+	rows, err := r.pg.Pool.Query(ctx, `
+		SELECT id, name, version, body, is_active, created_at
+		FROM prompts
+		WHERE is_active`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []entity.Prompt
+	for rows.Next() {
+		var p entity.Prompt
+		var createdAt time.Time
+		if err := rows.Scan(&p.ID, &p.Name, &p.Version, &p.Body, &p.IsActive, &createdAt); err != nil {
+			return nil, err
+		}
+		p.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		result = append(result, p)
+	}
+
+	return result, nil
+}
+
+// Activate makes the given version the only active one for its name.
+// Version 0 deactivates all stored versions, so the file template is used.
+func (r *PromptRepo) Activate(ctx context.Context, name string, version int) error {
+	tx, err := r.pg.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE prompts SET is_active = FALSE WHERE name = $1 AND is_active`, name); err != nil {
+		return err
+	}
+
+	if version != 0 {
+		tag, err := tx.Exec(ctx, `UPDATE prompts SET is_active = TRUE WHERE name = $1 AND version = $2`, name, version)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return errors.New("prompt version not found")
+		}
+	}
+
+	return tx.Commit(ctx)
+}
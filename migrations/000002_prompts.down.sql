@@ -0,0 +1,3 @@
+ALTER TABLE chat DROP COLUMN IF EXISTS prompt_versions;
+
+DROP TABLE IF EXISTS prompts CASCADE;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS prompts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    version INT NOT NULL,
+    body TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (name, version)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS prompts_active_name_idx ON prompts (name) WHERE is_active;
+
+ALTER TABLE chat ADD COLUMN IF NOT EXISTS prompt_versions jsonb NOT NULL DEFAULT '{}'::jsonb;
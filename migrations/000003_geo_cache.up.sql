@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS geo_cache (
+    key VARCHAR(500) PRIMARY KEY,
+    url TEXT NOT NULL,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    source VARCHAR(50) NOT NULL,
+    failed BOOLEAN NOT NULL DEFAULT FALSE,
+    resolved_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS geo_cache_expires_at_idx ON geo_cache (expires_at);
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(500) NOT NULL,
+    normalized_name VARCHAR(500) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    industry VARCHAR(255) NOT NULL DEFAULT '',
+    founded_year INT NOT NULL DEFAULT 0,
+    address TEXT NOT NULL DEFAULT '',
+    city VARCHAR(255) NOT NULL DEFAULT '',
+    country VARCHAR(100) NOT NULL DEFAULT '',
+    phone VARCHAR(255) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    website VARCHAR(500) NOT NULL DEFAULT '',
+    social_media jsonb NOT NULL DEFAULT '{}'::jsonb,
+    tax_id VARCHAR(50) NOT NULL DEFAULT '',
+    registration_number VARCHAR(100) NOT NULL DEFAULT '',
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    sources TEXT[] NOT NULL DEFAULT '{}',
+    images_url TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS organizations_tax_id_idx ON organizations (tax_id) WHERE tax_id <> '' AND deleted_at = 0;
+CREATE INDEX IF NOT EXISTS organizations_normalized_name_idx ON organizations (normalized_name);
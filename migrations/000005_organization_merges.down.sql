@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS organization_merge_candidates;
+
+DROP INDEX IF EXISTS organizations_website_key_idx;
+DROP INDEX IF EXISTS organizations_phone_key_idx;
+DROP INDEX IF EXISTS organizations_name_key_first_idx;
+DROP INDEX IF EXISTS organizations_name_key_idx;
+
+ALTER TABLE organizations
+    DROP COLUMN IF EXISTS merged_into,
+    DROP COLUMN IF EXISTS website_key,
+    DROP COLUMN IF EXISTS phone_key,
+    DROP COLUMN IF EXISTS name_key;
@@ -0,0 +1,27 @@
+ALTER TABLE organizations
+    ADD COLUMN IF NOT EXISTS name_key VARCHAR(500) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS phone_key VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS website_key VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS merged_into UUID REFERENCES organizations(id);
+
+UPDATE organizations SET name_key = normalized_name WHERE name_key = '';
+
+CREATE INDEX IF NOT EXISTS organizations_name_key_idx ON organizations (name_key);
+CREATE INDEX IF NOT EXISTS organizations_name_key_first_idx ON organizations (split_part(name_key, ' ', 1));
+CREATE INDEX IF NOT EXISTS organizations_phone_key_idx ON organizations (phone_key) WHERE phone_key <> '';
+CREATE INDEX IF NOT EXISTS organizations_website_key_idx ON organizations (website_key) WHERE website_key <> '';
+
+CREATE TABLE IF NOT EXISTS organization_merge_candidates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    duplicate_id UUID NOT NULL REFERENCES organizations(id),
+    confidence DOUBLE PRECISION NOT NULL,
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'rejected', 'obsolete')),
+    decided_by UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    decided_at TIMESTAMP,
+    UNIQUE (organization_id, duplicate_id)
+);
+
+CREATE INDEX IF NOT EXISTS organization_merge_candidates_status_idx ON organization_merge_candidates (status, confidence DESC);
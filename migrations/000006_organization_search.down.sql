@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS organizations_industry_idx;
+DROP INDEX IF EXISTS organizations_city_idx;
+DROP INDEX IF EXISTS organizations_location_idx;
+DROP INDEX IF EXISTS organizations_search_idx;
+
+ALTER TABLE organizations DROP COLUMN IF EXISTS search;
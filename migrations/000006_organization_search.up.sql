@@ -0,0 +1,10 @@
+ALTER TABLE organizations
+    ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS organizations_search_idx ON organizations USING GIN (search);
+CREATE INDEX IF NOT EXISTS organizations_location_idx ON organizations (latitude, longitude) WHERE latitude IS NOT NULL AND deleted_at = 0;
+CREATE INDEX IF NOT EXISTS organizations_city_idx ON organizations (lower(city));
+CREATE INDEX IF NOT EXISTS organizations_industry_idx ON organizations (lower(industry));
@@ -0,0 +1,4 @@
+ALTER TABLE chat DROP COLUMN IF EXISTS documents;
+
+DROP TABLE IF EXISTS kb_chunks;
+DROP TABLE IF EXISTS kb_documents;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS kb_documents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(500) NOT NULL,
+    file_name VARCHAR(500) NOT NULL,
+    url TEXT NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    chunk_count INT NOT NULL DEFAULT 0,
+    uploaded_by UUID REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS kb_documents_tags_idx ON kb_documents USING GIN (tags);
+
+CREATE TABLE IF NOT EXISTS kb_chunks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    document_id UUID NOT NULL REFERENCES kb_documents(id),
+    position INT NOT NULL,
+    text TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (document_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS kb_chunks_updated_at_idx ON kb_chunks (updated_at);
+
+ALTER TABLE chat ADD COLUMN IF NOT EXISTS documents jsonb NOT NULL DEFAULT '[]'::jsonb;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS organization_revisions;
+
+DROP INDEX IF EXISTS organizations_verified_at_idx;
+
+ALTER TABLE organizations
+    DROP COLUMN IF EXISTS verified_at,
+    DROP COLUMN IF EXISTS field_sources;
@@ -0,0 +1,45 @@
+ALTER TABLE organizations
+    ADD COLUMN IF NOT EXISTS field_sources jsonb NOT NULL DEFAULT '{}'::jsonb,
+    ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- What is known so far was observed when the row was last written.
+UPDATE organizations SET
+    verified_at = updated_at,
+    field_sources = (
+        SELECT COALESCE(jsonb_object_agg(f, jsonb_build_object(
+            'observed_at', to_char(updated_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+            'source_url', COALESCE(sources[1], ''))), '{}'::jsonb)
+        FROM unnest(ARRAY[
+            'name',
+            CASE WHEN description <> '' THEN 'description' END,
+            CASE WHEN industry <> '' THEN 'industry' END,
+            CASE WHEN founded_year <> 0 THEN 'founded_year' END,
+            CASE WHEN address <> '' THEN 'address' END,
+            CASE WHEN city <> '' THEN 'city' END,
+            CASE WHEN country <> '' THEN 'country' END,
+            CASE WHEN phone <> '' THEN 'phone' END,
+            CASE WHEN email <> '' THEN 'email' END,
+            CASE WHEN website <> '' THEN 'website' END,
+            CASE WHEN social_media <> '{}'::jsonb THEN 'social_media' END,
+            CASE WHEN tax_id <> '' THEN 'tax_id' END,
+            CASE WHEN registration_number <> '' THEN 'registration_number' END,
+            CASE WHEN latitude IS NOT NULL THEN 'location' END
+        ]) AS f
+        WHERE f IS NOT NULL)
+WHERE field_sources = '{}'::jsonb;
+
+CREATE INDEX IF NOT EXISTS organizations_verified_at_idx ON organizations (verified_at) WHERE deleted_at = 0;
+
+CREATE TABLE IF NOT EXISTS organization_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    field VARCHAR(50) NOT NULL,
+    old_value TEXT NOT NULL DEFAULT '',
+    new_value TEXT NOT NULL DEFAULT '',
+    source_url TEXT NOT NULL DEFAULT '',
+    observed_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS organization_revisions_organization_idx ON organization_revisions (organization_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS organization_revisions_created_at_idx ON organization_revisions (created_at DESC);
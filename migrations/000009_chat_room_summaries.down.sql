@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS chat_chat_room_id_created_at_idx;
+
+ALTER TABLE chat_rooms
+    DROP COLUMN IF EXISTS summarized_at,
+    DROP COLUMN IF EXISTS summary_exchanges,
+    DROP COLUMN IF EXISTS summary;
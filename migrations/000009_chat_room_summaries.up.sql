@@ -0,0 +1,6 @@
+ALTER TABLE chat_rooms
+    ADD COLUMN IF NOT EXISTS summary TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS summary_exchanges INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS summarized_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS chat_chat_room_id_created_at_idx ON chat (chat_room_id, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE chat
+    DROP COLUMN IF EXISTS interrupted;
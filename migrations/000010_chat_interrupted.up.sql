@@ -0,0 +1,2 @@
+ALTER TABLE chat
+    ADD COLUMN IF NOT EXISTS interrupted BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS chat_message_id_idx;
+
+ALTER TABLE chat
+    DROP COLUMN IF EXISTS message_id;
@@ -0,0 +1,4 @@
+ALTER TABLE chat
+    ADD COLUMN IF NOT EXISTS message_id UUID;
+
+CREATE INDEX IF NOT EXISTS chat_message_id_idx ON chat (message_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS organizations_checked_at_idx;
+
+ALTER TABLE organizations
+    DROP COLUMN IF EXISTS checked_at;
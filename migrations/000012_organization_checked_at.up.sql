@@ -0,0 +1,7 @@
+ALTER TABLE organizations
+    ADD COLUMN IF NOT EXISTS checked_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- Every check so far confirmed the organization.
+UPDATE organizations SET checked_at = verified_at;
+
+CREATE INDEX IF NOT EXISTS organizations_checked_at_idx ON organizations (checked_at) WHERE deleted_at = 0;
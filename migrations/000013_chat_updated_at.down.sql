@@ -0,0 +1 @@
+DROP INDEX IF EXISTS chat_updated_at_idx;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS chat_updated_at_idx ON chat (updated_at);
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"chatbot/internal/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GetGeo returns the cached entry for key, or nil if there is none.
+func GetGeo(r *redis.Client, ctx context.Context, key string) (*entity.GeoCache, error) {
+	data, err := r.Get(ctx, fmt.Sprintf("geo:%s", key)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var res entity.GeoCache
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SetGeo caches the entry until it expires.
+func SetGeo(r *redis.Client, ctx context.Context, e *entity.GeoCache) error {
+	ttl := time.Until(e.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return r.Set(ctx, fmt.Sprintf("geo:%s", e.Key), data, ttl).Err()
+}
@@ -0,0 +1,41 @@
+package coords
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var yandexOrgRe = regexp.MustCompile(`/maps/org/(?:[^/]+/)?(\d+)`)
+
+// ignoredParams do not change what a map URL points to.
+var ignoredParams = []string{"lang", "utm_source", "utm_medium", "utm_campaign", "utm_content", "utm_term", "si", "source"}
+
+// CacheKey normalizes a map URL for the geocoding cache. Yandex org pages
+// are keyed by org id, so the same org behind another host or slug shares
+// the entry.
+func CacheKey(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return raw
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	if strings.HasPrefix(host, "yandex.") {
+		if m := yandexOrgRe.FindStringSubmatch(u.Path); m != nil {
+			return "yandex:org:" + m[1]
+		}
+	}
+
+	q := u.Query()
+	for _, p := range ignoredParams {
+		q.Del(p)
+	}
+
+	key := host + strings.TrimRight(u.Path, "/")
+	if enc := q.Encode(); enc != "" {
+		key += "?" + enc
+	}
+
+	return "url:" + key
+}
@@ -0,0 +1,28 @@
+package coords
+
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Option -.
+type Option func(*Resolver)
+
+// SeleniumFallback -.
+func SeleniumFallback(seleniumURL string) Option {
+	return func(r *Resolver) {
+		r.seleniumURL = seleniumURL
+	}
+}
+
+// Cache keeps resolved coordinates for ttl and failures for negativeTTL.
+// Either layer may be nil.
+func Cache(rdb *redis.Client, store Store, ttl, negativeTTL time.Duration) Option {
+	return func(r *Resolver) {
+		r.rdb = rdb
+		r.store = store
+		r.ttl = ttl
+		r.negativeTTL = negativeTTL
+	}
+}
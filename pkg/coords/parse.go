@@ -0,0 +1,246 @@
+package coords
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Uzbekistan bounding box.
+const (
+	MinLat = 37.17
+	MaxLat = 45.60
+	MinLng = 55.99
+	MaxLng = 73.15
+)
+
+var (
+	// ErrNotMapURL is returned for URLs of services ParseMapURL does not know.
+	ErrNotMapURL = errors.New("not a map url")
+
+	// ErrOutsideUzbekistan is returned for points outside the bounding box.
+	ErrOutsideUzbekistan = errors.New("point is outside Uzbekistan")
+)
+
+type service int
+
+const (
+	serviceUnknown service = iota
+	serviceGoogle
+	serviceYandex
+	service2GIS
+	serviceOSM
+)
+
+var (
+	googleDataRe = regexp.MustCompile(`!3d(-?\d+(?:\.\d+)?)!4d(-?\d+(?:\.\d+)?)`)
+	googleAtRe   = regexp.MustCompile(`@(-?\d+(?:\.\d+)?),(-?\d+(?:\.\d+)?)`)
+	twoGISGeoRe  = regexp.MustCompile(`/geo/[^/]+/(-?\d+(?:\.\d+)?),(-?\d+(?:\.\d+)?)`)
+)
+
+// IsMapURL reports whether raw points at Google Maps, Yandex Maps, 2GIS or
+// OpenStreetMap, short links included.
+func IsMapURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	return detect(u) != serviceUnknown
+}
+
+// IsShortLink reports whether raw is a map short link that has to be
+// followed before it can be parsed.
+func IsShortLink(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+
+	host := hostOf(u)
+	switch {
+	case host == "maps.app.goo.gl", host == "goo.gl" && strings.HasPrefix(u.Path, "/maps"):
+		return true
+	case strings.HasPrefix(host, "yandex.") && strings.HasPrefix(u.Path, "/maps/-/"):
+		return true
+	case host == "go.2gis.com":
+		return true
+	case host == "osm.org" && strings.HasPrefix(u.Path, "/go/"):
+		return true
+	}
+	return false
+}
+
+// ParseMapURL returns the point a map URL is about. A marker (place, pin,
+// "what's here") wins over the viewport center. It returns ErrNotMapURL for
+// other URLs, ErrNotFound when the URL has no coordinates (e.g. an org page
+// or a short link) and ErrOutsideUzbekistan for points outside the country.
+func ParseMapURL(raw string) (float64, float64, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrNotMapURL, err)
+	}
+
+	var lat, lng float64
+	switch detect(u) {
+	case serviceGoogle:
+		lat, lng, err = parseGoogle(u)
+	case serviceYandex:
+		lat, lng, err = parseYandex(u)
+	case service2GIS:
+		lat, lng, err = parse2GIS(u)
+	case serviceOSM:
+		lat, lng, err = parseOSM(u)
+	default:
+		return 0, 0, ErrNotMapURL
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return lat, lng, Validate(lat, lng)
+}
+
+// Validate checks that the point lies inside Uzbekistan.
+func Validate(lat, lng float64) error {
+	if !InUzbekistan(lat, lng) {
+		return fmt.Errorf("%w: %v, %v", ErrOutsideUzbekistan, lat, lng)
+	}
+	return nil
+}
+
+// InUzbekistan -.
+func InUzbekistan(lat, lng float64) bool {
+	return lat >= MinLat && lat <= MaxLat && lng >= MinLng && lng <= MaxLng
+}
+
+func hostOf(u *url.URL) string {
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+func detect(u *url.URL) service {
+	host := hostOf(u)
+	switch {
+	case host == "maps.app.goo.gl", host == "goo.gl" && strings.HasPrefix(u.Path, "/maps"):
+		return serviceGoogle
+	case strings.HasPrefix(host, "maps.google."):
+		return serviceGoogle
+	case strings.HasPrefix(host, "google.") && strings.HasPrefix(u.Path, "/maps"):
+		return serviceGoogle
+	case strings.HasPrefix(host, "yandex.") && strings.HasPrefix(u.Path, "/maps"):
+		return serviceYandex
+	case host == "maps.yandex.ru", host == "maps.yandex.com":
+		return serviceYandex
+	case host == "2gis.uz", host == "2gis.ru", host == "2gis.com", host == "go.2gis.com",
+		strings.HasSuffix(host, ".2gis.uz"), strings.HasSuffix(host, ".2gis.ru"), strings.HasSuffix(host, ".2gis.com"):
+		return service2GIS
+	case host == "openstreetmap.org", host == "osm.org", strings.HasSuffix(host, ".openstreetmap.org"):
+		return serviceOSM
+	}
+	return serviceUnknown
+}
+
+func parseGoogle(u *url.URL) (float64, float64, error) {
+	full := u.Path + "?" + u.RawQuery
+	if decoded, err := url.PathUnescape(full); err == nil {
+		full = decoded
+	}
+
+	// !3d!4d is the place itself, @ is only the viewport.
+	if m := googleDataRe.FindStringSubmatch(full); m != nil {
+		return parseLatLng(m[1], m[2])
+	}
+
+	q := u.Query()
+	for _, key := range []string{"q", "query", "destination", "daddr", "ll", "center"} {
+		if lat, lng, err := splitLatLng(q.Get(key)); err == nil {
+			return lat, lng, nil
+		}
+	}
+
+	if m := googleAtRe.FindStringSubmatch(full); m != nil {
+		return parseLatLng(m[1], m[2])
+	}
+
+	return 0, 0, ErrNotFound
+}
+
+func parseYandex(u *url.URL) (float64, float64, error) {
+	q := u.Query()
+
+	// Yandex writes points as "lng,lat".
+	for _, key := range []string{"whatshere[point]", "pt", "ll"} {
+		v := q.Get(key)
+		if key == "pt" {
+			// Several points are separated by "~", the first one wins.
+			v = strings.SplitN(v, "~", 2)[0]
+		}
+		if lat, lng, err := splitLngLat(v); err == nil {
+			return lat, lng, nil
+		}
+	}
+
+	return 0, 0, ErrNotFound
+}
+
+func parse2GIS(u *url.URL) (float64, float64, error) {
+	if m := twoGISGeoRe.FindStringSubmatch(u.Path); m != nil {
+		return parseLatLng(m[2], m[1])
+	}
+
+	// m=lng,lat/zoom is the viewport.
+	if m := u.Query().Get("m"); m != "" {
+		return splitLngLat(strings.SplitN(m, "/", 2)[0])
+	}
+
+	return 0, 0, ErrNotFound
+}
+
+func parseOSM(u *url.URL) (float64, float64, error) {
+	q := u.Query()
+	if q.Get("mlat") != "" {
+		return parseLatLng(q.Get("mlat"), q.Get("mlon"))
+	}
+
+	// #map=zoom/lat/lng is the viewport.
+	frag, _ := url.ParseQuery(u.Fragment)
+	if parts := strings.Split(frag.Get("map"), "/"); len(parts) == 3 {
+		return parseLatLng(parts[1], parts[2])
+	}
+
+	return 0, 0, ErrNotFound
+}
+
+func splitLatLng(v string) (float64, float64, error) {
+	parts := strings.Split(v, ",")
+	if len(parts) < 2 {
+		return 0, 0, ErrNotFound
+	}
+	return parseLatLng(parts[0], parts[1])
+}
+
+func splitLngLat(v string) (float64, float64, error) {
+	parts := strings.Split(v, ",")
+	if len(parts) < 2 {
+		return 0, 0, ErrNotFound
+	}
+	return parseLatLng(parts[1], parts[0])
+}
+
+func parseLatLng(latStr, lngStr string) (float64, float64, error) {
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid latitude %q", ErrNotFound, latStr)
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid longitude %q", ErrNotFound, lngStr)
+	}
+	if math.IsNaN(lat) || math.IsNaN(lng) || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, fmt.Errorf("%w: coordinates out of range: %v, %v", ErrNotFound, lat, lng)
+	}
+	return lat, lng, nil
+}
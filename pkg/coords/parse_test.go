@@ -0,0 +1,144 @@
+package coords
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMapURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		lat, lng float64
+		err      error
+	}{
+		{
+			name: "google at viewport",
+			url:  "https://www.google.com/maps/@41.311081,69.240562,15z",
+			lat:  41.311081, lng: 69.240562,
+		},
+		{
+			name: "google place data wins over viewport",
+			url:  "https://www.google.com/maps/place/Hamkorbank/@41.30,69.20,17z/data=!3m1!4b1!4m6!3m5!1s0x0:0x0!8m2!3d41.2995!4d69.2401",
+			lat:  41.2995, lng: 69.2401,
+		},
+		{
+			name: "google q",
+			url:  "https://maps.google.com/?q=41.311081,69.240562",
+			lat:  41.311081, lng: 69.240562,
+		},
+		{
+			name: "google ll",
+			url:  "https://maps.google.com/maps?ll=39.654,66.975&z=14",
+			lat:  39.654, lng: 66.975,
+		},
+		{
+			name: "yandex ll is lng,lat",
+			url:  "https://yandex.uz/maps/10335/tashkent/?ll=69.240562%2C41.311081&z=16",
+			lat:  41.311081, lng: 69.240562,
+		},
+		{
+			name: "yandex pt wins over ll",
+			url:  "https://yandex.ru/maps/?ll=69.0,41.0&pt=69.279737,41.311151~69.3,41.4&z=12",
+			lat:  41.311151, lng: 69.279737,
+		},
+		{
+			name: "yandex whatshere",
+			url:  "https://yandex.uz/maps/?whatshere%5Bpoint%5D=64.421,39.767&whatshere%5Bzoom%5D=17",
+			lat:  39.767, lng: 64.421,
+		},
+		{
+			name: "2gis geo",
+			url:  "https://2gis.uz/tashkent/geo/70000001019316385/69.279737,41.311151",
+			lat:  41.311151, lng: 69.279737,
+		},
+		{
+			name: "2gis m viewport",
+			url:  "https://2gis.uz/tashkent?m=69.279737%2C41.311151%2F16",
+			lat:  41.311151, lng: 69.279737,
+		},
+		{
+			name: "osm marker",
+			url:  "https://www.openstreetmap.org/?mlat=40.3834&mlon=71.7870#map=16/40.3834/71.7870",
+			lat:  40.3834, lng: 71.7870,
+		},
+		{
+			name: "outside uzbekistan",
+			url:  "https://www.google.com/maps/@55.755826,37.617300,15z",
+			err:  ErrOutsideUzbekistan,
+		},
+		{
+			name: "google swapped lng,lat",
+			url:  "https://maps.google.com/?q=69.240562,41.311081",
+			err:  ErrOutsideUzbekistan,
+		},
+		{
+			name: "yandex swapped lat,lng",
+			url:  "https://yandex.uz/maps/?ll=41.311081,69.240562",
+			err:  ErrOutsideUzbekistan,
+		},
+		{
+			name: "latitude out of range",
+			url:  "https://maps.google.com/?q=141.3,69.2",
+			err:  ErrNotFound,
+		},
+		{
+			name: "garbage coordinates",
+			url:  "https://maps.google.com/?q=abc,def",
+			err:  ErrNotFound,
+		},
+		{
+			name: "org page without coordinates",
+			url:  "https://yandex.uz/maps/org/hamkorbank/1234567890/",
+			err:  ErrNotFound,
+		},
+		{
+			name: "not a map",
+			url:  "https://hamkorbank.uz/contacts",
+			err:  ErrNotMapURL,
+		},
+		{
+			name: "garbage url",
+			url:  "::not a url",
+			err:  ErrNotMapURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lng, err := ParseMapURL(tt.url)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ParseMapURL(%q) error = %v, want %v", tt.url, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMapURL(%q) error = %v", tt.url, err)
+			}
+			if math.Abs(lat-tt.lat) > 1e-9 || math.Abs(lng-tt.lng) > 1e-9 {
+				t.Errorf("ParseMapURL(%q) = %v, %v, want %v, %v", tt.url, lat, lng, tt.lat, tt.lng)
+			}
+		})
+	}
+}
+
+func TestIsShortLink(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://maps.app.goo.gl/AbCdEf123", true},
+		{"https://yandex.uz/maps/-/CDqZ4W~x", true},
+		{"https://go.2gis.com/abc12", true},
+		{"https://www.google.com/maps/@41.3,69.2,15z", false},
+		{"https://hamkorbank.uz", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsShortLink(tt.url); got != tt.want {
+			t.Errorf("IsShortLink(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package coords resolves the coordinates of organizations found on map
+// services.
+package coords
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"chatbot/internal/entity"
+	"chatbot/pkg/cache"
+	"chatbot/pkg/httpclient"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	SourceHTML      = "yandex_html"
+	SourceSelenium  = "selenium"
+	SourceShortLink = "short_link"
+
+	_maxPageSize  = 4 << 20
+	_maxRedirects = 5
+	_userAgent    = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36"
+)
+
+// Store is the persistent layer of the geocoding cache.
+type Store interface {
+	Get(ctx context.Context, key string) (*entity.GeoCache, error)
+	Upsert(ctx context.Context, req *entity.GeoCache) error
+}
+
+// Resolver turns map URLs into points. URLs that carry coordinates are
+// parsed in place, short links are followed, and Yandex org pages are
+// fetched over plain HTTP; if such a page can not be parsed and a Selenium
+// URL is set, it falls back to the browser. With a cache configured,
+// results (and failures, for a shorter time) are kept in Redis and in the
+// geo_cache table.
+type Resolver struct {
+	client      *http.Client
+	seleniumURL string
+
+	rdb         *redis.Client
+	store       Store
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+func NewResolver(client *http.Client, opts ...Option) *Resolver {
+	if client == nil {
+		client = httpclient.New()
+	}
+
+	r := &Resolver{client: client}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Resolve returns latitude and longitude of the map URL. Points outside
+// Uzbekistan are rejected with ErrOutsideUzbekistan.
+func (r *Resolver) Resolve(ctx context.Context, pageURL string) (float64, float64, error) {
+	// Most map URLs carry the point, no request is needed.
+	lat, lng, err := ParseMapURL(pageURL)
+	if !errors.Is(err, ErrNotFound) {
+		return lat, lng, err
+	}
+
+	key := CacheKey(pageURL)
+	if e := r.cached(ctx, key); e != nil {
+		if e.Failed {
+			return 0, 0, fmt.Errorf("%w (cached)", ErrNotFound)
+		}
+		return e.Latitude, e.Longitude, nil
+	}
+
+	lat, lng, source, err := r.resolve(ctx, pageURL)
+	if err == nil {
+		err = Validate(lat, lng)
+	}
+	if ctx.Err() != nil {
+		return 0, 0, ctx.Err()
+	}
+
+	// Transient failures are not remembered, the next question retries.
+	if err == nil || !httpclient.Retryable(err) {
+		r.remember(ctx, &entity.GeoCache{
+			Key:       key,
+			URL:       pageURL,
+			Latitude:  lat,
+			Longitude: lng,
+			Source:    source,
+			Failed:    err != nil,
+		})
+	}
+
+	return lat, lng, err
+}
+
+func (r *Resolver) resolve(ctx context.Context, pageURL string) (float64, float64, string, error) {
+	if IsShortLink(pageURL) {
+		expanded, err := r.expand(ctx, pageURL)
+		if err != nil {
+			return 0, 0, SourceShortLink, err
+		}
+		lat, lng, err := ParseMapURL(expanded)
+		if !errors.Is(err, ErrNotFound) {
+			return lat, lng, SourceShortLink, err
+		}
+		pageURL = expanded
+	}
+
+	if !isYandexOrg(pageURL) {
+		return 0, 0, SourceHTML, ErrNotFound
+	}
+
+	lat, lng, err := r.fetch(ctx, pageURL)
+	if err == nil {
+		return lat, lng, SourceHTML, nil
+	}
+
+	if r.seleniumURL == "" || ctx.Err() != nil {
+		return 0, 0, SourceHTML, err
+	}
+
+	slog.Warn("HTTP coordinate resolve failed, falling back to Selenium", "url", pageURL, "err", err)
+	lat, lng, err = ExtractCoordinates(ctx, r.seleniumURL, pageURL)
+	return lat, lng, SourceSelenium, err
+}
+
+// cached looks in Redis first and then in the store, warming Redis on a
+// store hit. Cache errors are logged and read as a miss.
+func (r *Resolver) cached(ctx context.Context, key string) *entity.GeoCache {
+	if r.rdb != nil {
+		e, err := cache.GetGeo(r.rdb, ctx, key)
+		if err != nil {
+			slog.Warn("Geo cache read error", "key", key, "err", err)
+		}
+		if e != nil {
+			return e
+		}
+	}
+
+	if r.store == nil {
+		return nil
+	}
+
+	e, err := r.store.Get(ctx, key)
+	if err != nil {
+		slog.Warn("Geo cache store read error", "key", key, "err", err)
+		return nil
+	}
+	if e != nil && r.rdb != nil {
+		if err := cache.SetGeo(r.rdb, ctx, e); err != nil {
+			slog.Warn("Geo cache write error", "key", key, "err", err)
+		}
+	}
+
+	return e
+}
+
+func (r *Resolver) remember(ctx context.Context, e *entity.GeoCache) {
+	if r.rdb == nil && r.store == nil {
+		return
+	}
+
+	ttl := r.ttl
+	if e.Failed {
+		ttl = r.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	e.ResolvedAt = time.Now()
+	e.ExpiresAt = e.ResolvedAt.Add(ttl)
+
+	if r.store != nil {
+		if err := r.store.Upsert(ctx, e); err != nil {
+			slog.Warn("Geo cache store write error", "key", e.Key, "err", err)
+		}
+	}
+	if r.rdb != nil {
+		if err := cache.SetGeo(r.rdb, ctx, e); err != nil {
+			slog.Warn("Geo cache write error", "key", e.Key, "err", err)
+		}
+	}
+}
+
+// expand follows the redirects of a short link without loading the target.
+func (r *Resolver) expand(ctx context.Context, link string) (string, error) {
+	client := *r.client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	current := link
+	for i := 0; i < _maxRedirects && IsShortLink(current); i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, current, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("User-Agent", _userAgent)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+
+		loc := resp.Header.Get("Location")
+		if loc == "" {
+			return "", fmt.Errorf("coords - expand: no redirect from %s (status %d)", current, resp.StatusCode)
+		}
+		next, err := resp.Request.URL.Parse(loc)
+		if err != nil {
+			return "", err
+		}
+		current = next.String()
+	}
+
+	return current, nil
+}
+
+func isYandexOrg(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(hostOf(u), "yandex.") && yandexOrgRe.MatchString(u.Path)
+}
+
+func (r *Resolver) fetch(ctx context.Context, pageURL string) (float64, float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("User-Agent", _userAgent)
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("Accept-Language", "uz,ru;q=0.9,en;q=0.8")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := httpclient.CheckStatus(resp); err != nil {
+		return 0, 0, fmt.Errorf("coords - fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	page, err := io.ReadAll(io.LimitReader(resp.Body, _maxPageSize))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return ParseYandexHTML(page)
+}
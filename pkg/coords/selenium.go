@@ -1,19 +1,23 @@
 package coords
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/tebeka/selenium"
-)
+	"chatbot/pkg/httpclient"
 
-const (
-	seleniumURL = "http://selenium:4444/wd/hub"
+	"github.com/tebeka/selenium"
 )
 
-func ExtractCoordinates(url string) (float64, float64, error) {
+// ExtractCoordinates opens the Yandex org page in a remote Chrome and reads
+// the coordinates from the share card. It is slow and is only used when the
+// HTTP resolver fails. The browser session is closed when ctx is done, which
+// fails the WebDriver call in progress.
+func ExtractCoordinates(ctx context.Context, seleniumURL, url string) (float64, float64, error) {
 	caps := selenium.Capabilities{"browserName": "chrome"}
 	chromeArgs := []string{
 		"--disable-dev-shm-usage",
@@ -30,7 +34,20 @@ func ExtractCoordinates(url string) (float64, float64, error) {
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to connect to selenium: %w", err)
 	}
-	defer wd.Quit()
+	finished := make(chan struct{})
+	var quitOnce sync.Once
+	quit := func() { quitOnce.Do(func() { _ = wd.Quit() }) }
+	defer func() {
+		close(finished)
+		quit()
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			quit()
+		case <-finished:
+		}
+	}()
 
 	if !strings.Contains(url, "lang=uz") {
 		if strings.Contains(url, "?") {
@@ -48,13 +65,17 @@ func ExtractCoordinates(url string) (float64, float64, error) {
 
 	fmt.Println("Waiting for page to load...")
 
-	time.Sleep(5 * time.Second)
+	if err := httpclient.Wait(ctx, 5*time.Second); err != nil {
+		return 0, 0, err
+	}
 
 	shareBtn, err := wd.FindElement(selenium.ByCSSSelector, "button[aria-label='Baham ko‘rish']")
 	fmt.Println("Share button found:", err)
 	if err == nil {
 		_ = shareBtn.Click()
-		time.Sleep(2 * time.Second)
+		if err := httpclient.Wait(ctx, 2*time.Second); err != nil {
+			return 0, 0, err
+		}
 	}
 
 	var elems []selenium.WebElement
@@ -64,7 +85,9 @@ func ExtractCoordinates(url string) (float64, float64, error) {
 		if len(elems) > 0 {
 			break
 		}
-		time.Sleep(1 * time.Second)
+		if err := httpclient.Wait(ctx, time.Second); err != nil {
+			return 0, 0, err
+		}
 	}
 
 	fmt.Println("Coords elements found:", err)
@@ -0,0 +1,75 @@
+package coords
+
+import (
+	"errors"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a page carries no coordinates.
+	ErrNotFound = errors.New("coordinates not found")
+
+	// ErrCaptcha is returned when Yandex answers with its captcha page.
+	ErrCaptcha = errors.New("yandex captcha page")
+)
+
+var (
+	metaLatRe = regexp.MustCompile(`<meta[^>]+itemprop="latitude"[^>]+content="([-0-9.]+)"`)
+	metaLngRe = regexp.MustCompile(`<meta[^>]+itemprop="longitude"[^>]+content="([-0-9.]+)"`)
+
+	// The embedded state keeps the org point as [longitude, latitude].
+	stateCoordsRe = regexp.MustCompile(`"coordinates"\s*:\s*\[\s*([-0-9.]+)\s*,\s*([-0-9.]+)\s*\]`)
+
+	// Static map images, e.g. og:image, carry ll= or pt= as "lng,lat".
+	imageRe = regexp.MustCompile(`<meta[^>]+property="og:image"[^>]+content="([^"]+)"`)
+)
+
+// ParseYandexHTML reads the coordinates of the organization from a Yandex
+// Maps org page. It looks at schema.org meta tags first, then at the JSON
+// state embedded in the page, then at the static map in og:image.
+func ParseYandexHTML(page []byte) (float64, float64, error) {
+	s := string(page)
+
+	if strings.Contains(s, "showcaptcha") || strings.Contains(s, "SmartCaptcha") {
+		return 0, 0, ErrCaptcha
+	}
+
+	if lat, ok := submatch(metaLatRe, s); ok {
+		if lng, ok := submatch(metaLngRe, s); ok {
+			if la, lo, err := parseLatLng(lat, lng); err == nil {
+				return la, lo, nil
+			}
+		}
+	}
+
+	if m := stateCoordsRe.FindStringSubmatch(s); m != nil {
+		if la, lo, err := parseLatLng(m[2], m[1]); err == nil {
+			return la, lo, nil
+		}
+	}
+
+	if img, ok := submatch(imageRe, s); ok {
+		if u, err := url.Parse(html.UnescapeString(img)); err == nil {
+			for _, key := range []string{"pt", "ll"} {
+				if v := u.Query().Get(key); v != "" {
+					if la, lo, err := splitLngLat(v); err == nil {
+						return la, lo, nil
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, ErrNotFound
+}
+
+func submatch(re *regexp.Regexp, s string) (string, bool) {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
@@ -0,0 +1,53 @@
+package coords
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseYandexHTML(t *testing.T) {
+	tests := []struct {
+		file     string
+		lat, lng float64
+		err      error
+	}{
+		// schema.org meta tags win over the og:image viewport.
+		{file: "yandex_org_meta.html", lat: 41.338396, lng: 69.285171},
+		{file: "yandex_org_state.html", lat: 39.654521, lng: 66.975406},
+		{file: "yandex_org_og_image.html", lat: 40.386532, lng: 71.784410},
+		{file: "yandex_captcha.html", err: ErrCaptcha},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			page, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lat, lng, err := ParseYandexHTML(page)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ParseYandexHTML() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseYandexHTML() error = %v", err)
+			}
+			if math.Abs(lat-tt.lat) > 1e-9 || math.Abs(lng-tt.lng) > 1e-9 {
+				t.Errorf("ParseYandexHTML() = %v, %v, want %v, %v", lat, lng, tt.lat, tt.lng)
+			}
+		})
+	}
+}
+
+func TestParseYandexHTMLNotFound(t *testing.T) {
+	_, _, err := ParseYandexHTML([]byte(`<html><head><title>Yandex Xaritalar</title></head><body></body></html>`))
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ParseYandexHTML() error = %v, want %v", err, ErrNotFound)
+	}
+}
@@ -0,0 +1,75 @@
+package directory
+
+import (
+	"chatbot/internal/entity"
+	"chatbot/pkg/cache"
+	"strings"
+)
+
+// FromOrgInfo converts a structured Sonar organization.
+func FromOrgInfo(o entity.OrgInfo) entity.Organization {
+	var res entity.Organization
+	res.Name = strings.TrimSpace(o.Name)
+	res.Description = o.Description
+	res.Address = o.Address
+	res.Phone = o.Phone
+	res.Email = o.Email
+	res.Website = o.Website
+	res.Location.Latitude = o.Location.Latitude
+	res.Location.Longitude = o.Location.Longitude
+	res.Sources = o.Sources
+	res.ImagesURL = o.ImagesURL
+	return res
+}
+
+// FromCache converts an organization extracted by the LLM. sources are the
+// citations of the answer it was extracted from.
+func FromCache(o cache.Organization, sources []string) entity.Organization {
+	var res entity.Organization
+	res.Name = strings.TrimSpace(o.Name)
+	res.Description = o.Description
+	res.Industry = o.Industry
+	res.FoundedYear = o.FoundedYear
+	res.Address = o.Headquarters.Address
+	res.City = o.Headquarters.City
+	res.Country = o.Headquarters.Country
+	res.Phone = o.Contacts.Phone
+	res.Email = o.Contacts.Email
+	res.Website = o.Contacts.Website
+	res.TaxID = strings.TrimSpace(o.Registration.TaxID)
+	res.RegistrationNumber = o.Registration.RegistrationNumber
+	res.Sources = sources
+
+	social := map[string]string{
+		"linkedin":  o.Contacts.SocialMedia.LinkedIn,
+		"twitter":   o.Contacts.SocialMedia.Twitter,
+		"instagram": o.Contacts.SocialMedia.Instagram,
+		"telegram":  o.Contacts.SocialMedia.Telegram,
+	}
+	for k, v := range social {
+		if v == "" {
+			delete(social, k)
+		}
+	}
+	res.SocialMedia = social
+
+	return res
+}
+
+// ToOrgInfo converts a directory organization to the shape streamed to
+// clients.
+func ToOrgInfo(o entity.Organization) entity.OrgInfo {
+	var res entity.OrgInfo
+	res.ID = o.ID
+	res.Name = o.Name
+	res.Address = o.Address
+	res.Location.Latitude = o.Location.Latitude
+	res.Location.Longitude = o.Location.Longitude
+	res.Phone = o.Phone
+	res.Email = o.Email
+	res.Description = o.Description
+	res.Website = o.Website
+	res.Sources = o.Sources
+	res.ImagesURL = o.ImagesURL
+	return res
+}
@@ -0,0 +1,172 @@
+package directory
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"chatbot/internal/entity"
+)
+
+// Organization fields tracked in entity.Organization.FieldSources.
+const (
+	FieldName               = "name"
+	FieldDescription        = "description"
+	FieldIndustry           = "industry"
+	FieldFoundedYear        = "founded_year"
+	FieldAddress            = "address"
+	FieldCity               = "city"
+	FieldCountry            = "country"
+	FieldPhone              = "phone"
+	FieldEmail              = "email"
+	FieldWebsite            = "website"
+	FieldSocialMedia        = "social_media"
+	FieldTaxID              = "tax_id"
+	FieldRegistrationNumber = "registration_number"
+	FieldLocation           = "location"
+)
+
+// verifiedFields are the facts re-verification diffs. Names and
+// descriptions are reworded on every search and are not compared.
+var verifiedFields = []string{FieldAddress, FieldPhone, FieldEmail, FieldWebsite, FieldLocation}
+
+// Observe returns the provenance of every field o has a value for.
+func Observe(o entity.Organization, sourceURL string, at time.Time) map[string]entity.FieldSource {
+	res := map[string]entity.FieldSource{}
+	for field, value := range fieldValues(o) {
+		if value != "" {
+			res[field] = entity.FieldSource{ObservedAt: at, SourceURL: sourceURL}
+		}
+	}
+	return res
+}
+
+// SourceURL is the source a freshly extracted organization is attributed to.
+func SourceURL(o entity.Organization) string {
+	for _, s := range o.Sources {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// Diff applies what re-verification found to known. Fields found with the
+// same value are marked as observed again; changed ones are overwritten and
+// returned as revisions. Fields found empty keep their known value.
+func Diff(known, found entity.Organization, sourceURL string, at time.Time) (entity.Organization, []entity.OrganizationRevision) {
+	updated := known
+	updated.FieldSources = map[string]entity.FieldSource{}
+	for k, v := range known.FieldSources {
+		updated.FieldSources[k] = v
+	}
+
+	oldValues, newValues := fieldValues(known), fieldValues(found)
+
+	var revisions []entity.OrganizationRevision
+	for _, field := range verifiedFields {
+		value := newValues[field]
+		if value == "" {
+			continue
+		}
+		updated.FieldSources[field] = entity.FieldSource{ObservedAt: at, SourceURL: sourceURL}
+		if sameValue(field, known, found) {
+			continue
+		}
+
+		revisions = append(revisions, entity.OrganizationRevision{
+			OrganizationID: known.ID,
+			Field:          field,
+			OldValue:       oldValues[field],
+			NewValue:       value,
+			SourceURL:      sourceURL,
+			ObservedAt:     at.Format("2006-01-02 15:04:05"),
+		})
+		setField(&updated, found, field)
+	}
+
+	return updated, revisions
+}
+
+func sameValue(field string, a, b entity.Organization) bool {
+	switch field {
+	case FieldPhone:
+		return sameKey(NormalizePhone, a.Phone, b.Phone)
+	case FieldWebsite:
+		return sameKey(NormalizeWebsite, a.Website, b.Website)
+	case FieldLocation:
+		return hasLocation(a) && Distance(a.Location.Latitude, a.Location.Longitude, b.Location.Latitude, b.Location.Longitude) <= nearMeters
+	case FieldAddress:
+		return normalizeText(a.Address) == normalizeText(b.Address)
+	case FieldEmail:
+		return normalizeText(a.Email) == normalizeText(b.Email)
+	}
+	return false
+}
+
+// sameKey compares by normalized key, or by text when a value does not
+// normalize.
+func sameKey(normalize func(string) string, a, b string) bool {
+	ka, kb := normalize(a), normalize(b)
+	if ka == "" || kb == "" {
+		return normalizeText(a) == normalizeText(b)
+	}
+	return ka == kb
+}
+
+func setField(dst *entity.Organization, src entity.Organization, field string) {
+	switch field {
+	case FieldAddress:
+		dst.Address = src.Address
+	case FieldPhone:
+		dst.Phone = src.Phone
+	case FieldEmail:
+		dst.Email = src.Email
+	case FieldWebsite:
+		dst.Website = src.Website
+	case FieldLocation:
+		dst.Location = src.Location
+	}
+}
+
+func fieldValues(o entity.Organization) map[string]string {
+	res := map[string]string{
+		FieldName:               o.Name,
+		FieldDescription:        o.Description,
+		FieldIndustry:           o.Industry,
+		FieldAddress:            o.Address,
+		FieldCity:               o.City,
+		FieldCountry:            o.Country,
+		FieldPhone:              o.Phone,
+		FieldEmail:              o.Email,
+		FieldWebsite:            o.Website,
+		FieldTaxID:              o.TaxID,
+		FieldRegistrationNumber: o.RegistrationNumber,
+	}
+	if o.FoundedYear != 0 {
+		res[FieldFoundedYear] = strconv.Itoa(o.FoundedYear)
+	}
+	if hasLocation(o) {
+		res[FieldLocation] = fmt.Sprintf("%.6f,%.6f", o.Location.Latitude, o.Location.Longitude)
+	}
+
+	var social []string
+	for k, v := range o.SocialMedia {
+		if v != "" {
+			social = append(social, k+"="+v)
+		}
+	}
+	sort.Strings(social)
+	res[FieldSocialMedia] = strings.Join(social, " ")
+
+	for k, v := range res {
+		res[k] = strings.TrimSpace(v)
+	}
+	return res
+}
+
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,283 @@
+package directory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"chatbot/internal/entity"
+	"chatbot/pkg/lang"
+)
+
+// Finder -.
+type Finder interface {
+	FindByNameWords(ctx context.Context, words []string, freshSince time.Time) ([]entity.Organization, error)
+}
+
+// Hit is the organization a query was matched to.
+type Hit struct {
+	Organization entity.Organization
+	Confidence   float64
+}
+
+// queryWeight is how much of the confidence depends on the query being
+// about the name and nothing else.
+const queryWeight = 0.2
+
+// minNameRunes is the shortest single word name matched on its own; shorter
+// ones are too often common words.
+const minNameRunes = 6
+
+// genericWords are place names many organizations carry in passing. They do
+// not tell one organization from another on their own.
+var genericWords = keySet(
+	"O‘zbekiston", "Ўзбекистон", "Uzbekistan", "Узбекистан",
+	"Toshkent", "Тошкент", "Tashkent", "Ташкент",
+	"Samarqand", "Самарқанд", "Samarkand", "Самарканд",
+	"Buxoro", "Бухоро", "Bukhara", "Бухара",
+	"Andijon", "Андижон", "Andijan", "Андижан",
+	"Namangan", "Наманган",
+	"Farg‘ona", "Фарғона", "Fergana", "Фергана",
+	"Qarshi", "Қарши", "Karshi", "Карши",
+	"Navoiy", "Навоий", "Navoi", "Навои",
+	"Jizzax", "Жиззах", "Jizzakh", "Джизак",
+	"Termiz", "Термиз", "Termez", "Термез",
+	"Urganch", "Урганч", "Urgench", "Ургенч",
+	"Guliston", "Гулистон", "Gulistan", "Гулистан",
+	"Nukus", "Нукус",
+	"Xorazm", "Хоразм", "Khorezm", "Хорезм",
+	"Qoraqalpog‘iston", "Қорақалпоғистон", "Karakalpakstan", "Каракалпакстан",
+	"shahar", "shahri", "шаҳар", "город",
+	"viloyat", "viloyati", "вилоят", "область",
+	"respublika", "республика",
+)
+
+// fieldWords are the words a query asks for a contact with, matched as
+// prefixes of inflected query words.
+var fieldWords = map[string]map[string]bool{
+	FieldAddress: keySet("manzil", "манзил", "адрес", "address", "joylash", "жойлаш", "qayerda", "қаерда", "где", "where", "location", "локация"),
+	FieldPhone:   keySet("telefon", "телефон", "phone", "raqam", "рақам", "номер", "nomer", "number"),
+	FieldEmail:   keySet("email", "mail", "pochta", "почта"),
+	FieldWebsite: keySet("sayt", "сайт", "website", "site"),
+}
+
+// Lookup finds the organization query is about among the ones updated or
+// re-verified within maxAge. The confidence is the share of the
+// organization's name found in the query, in any script, lowered by the
+// words of the query the name does not cover. Place names and the contacts
+// asked for are not counted against it. It returns nil when no organization reaches
+// minConfidence, when two match equally well, when the name is too generic
+// to match on, or when the match has nothing to answer with.
+func Lookup(ctx context.Context, finder Finder, query string, maxAge time.Duration, minConfidence float64) (*Hit, error) {
+	queryWords := strings.Fields(NameKey(query))
+	if len(queryWords) == 0 {
+		return nil, nil
+	}
+	fields := askedFields(queryWords)
+
+	candidates, err := finder.FindByNameWords(ctx, prefixes(queryWords), time.Now().Add(-maxAge))
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Hit
+	bestWords, ambiguous := 0, false
+	for _, o := range candidates {
+		nameWords := strings.Fields(NameKey(o.Name))
+		if !significant(nameWords) {
+			continue
+		}
+		confidence := coverage(nameWords, queryWords) * (1 - queryWeight*(1-queryCoverage(queryWords, nameWords)))
+		if confidence < minConfidence || !answerable(o, fields) {
+			continue
+		}
+
+		// The longer of two fully matched names is the more specific one,
+		// e.g. a branch over the bank.
+		switch {
+		case best == nil, confidence > best.Confidence,
+			confidence == best.Confidence && len(nameWords) > bestWords:
+			best = &Hit{Organization: o, Confidence: confidence}
+			bestWords, ambiguous = len(nameWords), false
+		case confidence == best.Confidence && len(nameWords) == bestWords && NameKey(o.Name) != NameKey(best.Organization.Name):
+			ambiguous = true
+		}
+	}
+	if ambiguous {
+		return nil, nil
+	}
+
+	return best, nil
+}
+
+// Text renders the organization as a short answer to query in the given
+// language: the contacts it asks for, or the whole card when it asks for
+// none in particular.
+func Text(o entity.Organization, query, language string) string {
+	fields := askedFields(strings.Fields(NameKey(query)))
+
+	var b strings.Builder
+	b.WriteString(o.Name)
+	if o.Description != "" && len(fields) == 0 {
+		b.WriteString("\n\n" + o.Description)
+	}
+
+	b.WriteString("\n")
+	for _, l := range contacts(o) {
+		if l.value != "" && (len(fields) == 0 || fields[l.field]) {
+			b.WriteString("\n" + l.icon + " " + lang.T(language, l.label) + ": " + l.value)
+		}
+	}
+
+	return b.String()
+}
+
+type contact struct{ field, icon, label, value string }
+
+func contacts(o entity.Organization) []contact {
+	return []contact{
+		{FieldAddress, "📍", lang.Address, joinNonEmpty(", ", o.Address, o.City)},
+		{FieldPhone, "📞", lang.Phone, o.Phone},
+		{FieldEmail, "✉️", lang.Email, o.Email},
+		{FieldWebsite, "🌐", lang.Website, o.Website},
+	}
+}
+
+// askedFields returns the contacts the query words ask for.
+func askedFields(queryWords []string) map[string]bool {
+	res := map[string]bool{}
+	for field, words := range fieldWords {
+		for _, q := range queryWords {
+			if hasPrefixIn(q, words) {
+				res[field] = true
+				break
+			}
+		}
+	}
+	return res
+}
+
+// significant reports whether a name tells an organization apart: two words
+// that are not place names, or one long one.
+func significant(nameWords []string) bool {
+	var words []string
+	for _, n := range nameWords {
+		if !genericWords[n] {
+			words = append(words, n)
+		}
+	}
+	return len(words) >= 2 || len(words) == 1 && len([]rune(words[0])) >= minNameRunes
+}
+
+// prefixes returns words and their prefixes down to four letters, so names
+// are found in inflected words like "Hamkorbankning".
+func prefixes(words []string) []string {
+	seen := map[string]bool{}
+	var res []string
+	for _, w := range words {
+		r := []rune(w)
+		for n := len(r); n > 0; n-- {
+			p := string(r[:n])
+			if !seen[p] {
+				seen[p] = true
+				res = append(res, p)
+			}
+			if n <= 4 {
+				break
+			}
+		}
+	}
+	return res
+}
+
+func coverage(nameWords, queryWords []string) float64 {
+	if len(nameWords) == 0 {
+		return 0
+	}
+
+	matched := 0
+	for _, n := range nameWords {
+		for _, q := range queryWords {
+			if q == n || len([]rune(n)) >= 4 && strings.HasPrefix(q, n) {
+				matched++
+				break
+			}
+		}
+	}
+	return float64(matched) / float64(len(nameWords))
+}
+
+// queryCoverage is the share of the query words the name covers. Place
+// names and the words asking for a contact are left out; a query of only
+// those is covered.
+func queryCoverage(queryWords, nameWords []string) float64 {
+	total, matched := 0, 0
+	for _, q := range queryWords {
+		if hasPrefixIn(q, genericWords) || len(askedFields([]string{q})) > 0 {
+			continue
+		}
+		total++
+		for _, n := range nameWords {
+			if q == n || len([]rune(n)) >= 4 && strings.HasPrefix(q, n) {
+				matched++
+				break
+			}
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(matched) / float64(total)
+}
+
+// answerable reports whether o has one of the asked contacts, or any to
+// answer with when none is asked for.
+func answerable(o entity.Organization, fields map[string]bool) bool {
+	if len(fields) == 0 {
+		return o.Address != "" || o.Phone != "" || o.Website != ""
+	}
+	for _, l := range contacts(o) {
+		if l.value != "" && fields[l.field] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPrefixIn reports whether word is one of keys, or an inflection of one
+// at least four letters long.
+func hasPrefixIn(word string, keys map[string]bool) bool {
+	if keys[word] {
+		return true
+	}
+	r := []rune(word)
+	for n := len(r) - 1; n >= 4; n-- {
+		if keys[string(r[:n])] {
+			return true
+		}
+	}
+	return false
+}
+
+// keySet returns the name keys of words.
+func keySet(words ...string) map[string]bool {
+	res := map[string]bool{}
+	for _, w := range words {
+		res[NameKey(w)] = true
+	}
+	return res
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	return strings.Join(nonEmpty(values), sep)
+}
+
+func nonEmpty(values []string) []string {
+	var res []string
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
@@ -0,0 +1,153 @@
+package directory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"chatbot/internal/entity"
+	"chatbot/pkg/lang"
+)
+
+type fakeFinder []entity.Organization
+
+func (f fakeFinder) FindByNameWords(ctx context.Context, words []string, freshSince time.Time) ([]entity.Organization, error) {
+	return f, nil
+}
+
+func org(name, address, phone, website string) entity.Organization {
+	return entity.Organization{ID: name, Name: name, Address: address, Phone: phone, Website: website}
+}
+
+func TestLookup(t *testing.T) {
+	directory := fakeFinder{
+		org("Hamkorbank", "Andijon, Bobur shoh ko‘chasi 85", "+998 71 200 00 00", "hamkorbank.uz"),
+		org("Kapitalbank Toshkent filiali", "Toshkent, Amir Temur 12", "", ""),
+		org("O‘zbekiston", "Toshkent, Islom Karimov 45", "+998 71 113 11 11", ""),
+		org("Toshkent shahri", "Toshkent", "", "tashkent.uz"),
+		org("Ipak", "Toshkent, Navoiy 1", "", ""),
+		org("Korzinka", "Toshkent, Bunyodkor 5", "", ""),
+		org("Artel Electronics", "", "", ""),
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "name only", query: "Hamkorbank", want: "Hamkorbank"},
+		{name: "other script", query: "Хамкорбанк манзили", want: "Hamkorbank"},
+		{name: "inflected with contact", query: "Hamkorbankning telefon raqami", want: "Hamkorbank"},
+		{name: "place words do not count", query: "Toshkentdagi Hamkorbank manzili qayerda", want: "Hamkorbank"},
+		{name: "more specific name wins", query: "Kapitalbank Toshkent filiali manzili", want: "Kapitalbank Toshkent filiali"},
+		{name: "query about more than the name", query: "Hamkorbank kredit foizlari qancha va qanday hujjatlar kerak", want: ""},
+		{name: "place name alone", query: "O‘zbekiston qayerda joylashgan", want: ""},
+		{name: "place name with city word", query: "Toshkent shahri sayti", want: ""},
+		{name: "short single word", query: "Ipak manzili", want: ""},
+		{name: "long single word", query: "Korzinka manzili", want: "Korzinka"},
+		{name: "part of the name", query: "Kapitalbank manzili", want: ""},
+		{name: "nothing to answer with", query: "Artel Electronics", want: ""},
+		{name: "asked contact missing", query: "Korzinka telefon raqami", want: ""},
+		{name: "empty", query: " ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, err := Lookup(context.Background(), directory, tt.query, time.Hour, 0.9)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if hit != nil {
+				got = hit.Organization.Name
+			}
+			if got != tt.want {
+				t.Errorf("Lookup(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupAmbiguous(t *testing.T) {
+	directory := fakeFinder{
+		org("Asaka bank Samarqand filiali", "Samarqand", "", ""),
+		org("Asaka bank Buxoro filiali", "Buxoro", "", ""),
+	}
+
+	// Both cover three of their four name words.
+	hit, err := Lookup(context.Background(), directory, "Asaka bank filiali", time.Hour, 0.7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hit != nil {
+		t.Errorf("Lookup() = %q, want nil", hit.Organization.Name)
+	}
+}
+
+func TestText(t *testing.T) {
+	o := entity.Organization{
+		Name:        "Hamkorbank",
+		Description: "Tijorat banki.",
+		Address:     "Bobur shoh ko‘chasi 85",
+		City:        "Andijon",
+		Phone:       "+998 71 200 00 00",
+		Email:       "info@hamkorbank.uz",
+		Website:     "hamkorbank.uz",
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		language string
+		want     []string
+		not      []string
+	}{
+		{
+			name:     "whole card",
+			query:    "Hamkorbank haqida",
+			language: lang.UzLatn,
+			want:     []string{"Tijorat banki.", "📍 Manzil: Bobur shoh ko‘chasi 85, Andijon", "📞 Telefon: +998 71 200 00 00", "✉️ E-pochta: info@hamkorbank.uz", "🌐 Sayt: hamkorbank.uz"},
+		},
+		{
+			name:     "phone",
+			query:    "Hamkorbank telefon raqami",
+			language: lang.UzLatn,
+			want:     []string{"📞 Telefon: +998 71 200 00 00"},
+			not:      []string{"Tijorat banki.", "📍", "✉️", "🌐"},
+		},
+		{
+			name:     "address in russian",
+			query:    "Где находится Хамкорбанк?",
+			language: lang.Ru,
+			want:     []string{"📍 Адрес: Bobur shoh ko‘chasi 85, Andijon"},
+			not:      []string{"📞", "✉️", "🌐"},
+		},
+		{
+			name:     "site and email in english",
+			query:    "Hamkorbank website and email",
+			language: lang.En,
+			want:     []string{"✉️ Email: info@hamkorbank.uz", "🌐 Website: hamkorbank.uz"},
+			not:      []string{"📍", "📞"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := Text(o, tt.query, tt.language)
+			if !strings.HasPrefix(text, "Hamkorbank") {
+				t.Errorf("Text(%q) = %q, want the name first", tt.query, text)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(text, w) {
+					t.Errorf("Text(%q) = %q, want %q in it", tt.query, text, w)
+				}
+			}
+			for _, n := range tt.not {
+				if strings.Contains(text, n) {
+					t.Errorf("Text(%q) = %q, want no %q in it", tt.query, text, n)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package directory
+
+import (
+	"math"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"chatbot/internal/entity"
+)
+
+const (
+	// AutoMergeConfidence and above is merged on upsert without review.
+	AutoMergeConfidence = 0.9
+
+	// CandidateConfidence and above is stored for an admin to review.
+	CandidateConfidence = 0.5
+
+	nearMeters = 150
+	farMeters  = 20000
+)
+
+// Match reasons.
+const (
+	ReasonTaxID       = "tax_id"
+	ReasonName        = "name"
+	ReasonSimilarName = "similar_name"
+	ReasonPhone       = "phone"
+	ReasonWebsite     = "website"
+	ReasonNear        = "near"
+	ReasonFar         = "far_apart"
+)
+
+// Match is the result of comparing two organizations.
+type Match struct {
+	Confidence float64
+	Reasons    []string
+}
+
+// Compare scores how likely a and b are the same organization. It is
+// deterministic: a tax ID decides on its own, the other signals add up.
+func Compare(a, b entity.Organization) Match {
+	var m Match
+
+	if a.TaxID != "" && b.TaxID != "" {
+		if NormalizeTaxID(a.TaxID) == NormalizeTaxID(b.TaxID) {
+			return Match{Confidence: 1, Reasons: []string{ReasonTaxID}}
+		}
+		// Two registrations are two organizations.
+		return m
+	}
+
+	score := 0.0
+
+	ka, kb := NameKey(a.Name), NameKey(b.Name)
+	switch sim := NameSimilarity(ka, kb); {
+	case ka != "" && ka == kb:
+		score += 0.9
+		m.Reasons = append(m.Reasons, ReasonName)
+	case sim >= 0.92:
+		score += 0.6
+		m.Reasons = append(m.Reasons, ReasonSimilarName)
+	case sim >= 0.85:
+		score += 0.4
+		m.Reasons = append(m.Reasons, ReasonSimilarName)
+	}
+
+	if pa, pb := NormalizePhone(a.Phone), NormalizePhone(b.Phone); pa != "" && pb != "" {
+		if pa == pb {
+			score += 0.3
+			m.Reasons = append(m.Reasons, ReasonPhone)
+		} else {
+			score -= 0.2
+		}
+	}
+
+	if wa, wb := NormalizeWebsite(a.Website), NormalizeWebsite(b.Website); wa != "" && wb != "" {
+		if wa == wb {
+			score += 0.3
+			m.Reasons = append(m.Reasons, ReasonWebsite)
+		} else {
+			score -= 0.2
+		}
+	}
+
+	if hasLocation(a) && hasLocation(b) {
+		d := Distance(a.Location.Latitude, a.Location.Longitude, b.Location.Latitude, b.Location.Longitude)
+		switch {
+		case d <= nearMeters:
+			score += 0.2
+			m.Reasons = append(m.Reasons, ReasonNear)
+		case d >= farMeters:
+			score -= 0.4
+			m.Reasons = append(m.Reasons, ReasonFar)
+		}
+	}
+
+	m.Confidence = math.Max(0, math.Min(1, score))
+	return m
+}
+
+// NameSimilarity compares two name keys: the better of Jaro-Winkler on the
+// whole key and the overlap of their words.
+func NameSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	return math.Max(jaroWinkler(a, b), tokenOverlap(a, b))
+}
+
+// NormalizePhone keeps the digits and reduces Uzbek numbers to their nine
+// digit national form, so "+998 (71) 200-00-00" equals "712000000".
+func NormalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+
+	if len(digits) == 12 && strings.HasPrefix(digits, "998") {
+		return digits[3:]
+	}
+	if len(digits) < 7 {
+		return ""
+	}
+	return digits
+}
+
+// NormalizeWebsite returns the host without "www.", lowercased.
+func NormalizeWebsite(website string) string {
+	website = strings.TrimSpace(strings.ToLower(website))
+	if website == "" {
+		return ""
+	}
+	if !strings.Contains(website, "://") {
+		website = "http://" + website
+	}
+
+	u, err := url.Parse(website)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// NormalizeTaxID keeps the digits of an INN.
+func NormalizeTaxID(taxID string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, taxID)
+}
+
+// Distance returns the great-circle distance in meters.
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6371000
+	rad := math.Pi / 180
+
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+func hasLocation(o entity.Organization) bool {
+	return o.Location.Latitude != 0 || o.Location.Longitude != 0
+}
+
+func tokenOverlap(a, b string) float64 {
+	ta, tb := strings.Fields(a), strings.Fields(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	set := map[string]bool{}
+	for _, t := range ta {
+		set[t] = true
+	}
+
+	common := 0
+	for _, t := range tb {
+		if set[t] {
+			common++
+			delete(set, t)
+		}
+	}
+
+	// Overlap of the shorter name, so "Kapitalbank" matches "Kapitalbank
+	// Toshkent filiali" less than fully but still strongly.
+	shorter := math.Min(float64(len(ta)), float64(len(tb)))
+	longer := math.Max(float64(len(ta)), float64(len(tb)))
+	return float64(common) / shorter * (0.7 + 0.3*shorter/longer)
+}
+
+func jaroWinkler(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := int(math.Max(float64(len(ra)), float64(len(rb))))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	ma := make([]bool, len(ra))
+	mb := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		lo := int(math.Max(0, float64(i-window)))
+		hi := int(math.Min(float64(len(rb)-1), float64(i+window)))
+		for j := lo; j <= hi; j++ {
+			if !mb[j] && ra[i] == rb[j] {
+				ma[i], mb[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range ra {
+		if !ma[i] {
+			continue
+		}
+		for !mb[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < 4 && prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
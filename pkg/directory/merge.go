@@ -0,0 +1,78 @@
+package directory
+
+import "chatbot/pkg/cache"
+
+// MergeChat merges the organizations extracted from an answer into the ones
+// already known in the chat. An extracted organization that matches a known
+// one with AutoMergeConfidence takes its values where it has none. The
+// extracted organizations come first, as the current ones, followed by the
+// known ones they did not match.
+func MergeChat(known, extracted []cache.Organization) []cache.Organization {
+	used := make([]bool, len(known))
+	res := make([]cache.Organization, 0, len(known)+len(extracted))
+
+	for _, e := range extracted {
+		ee := FromCache(e, nil)
+
+		best, bestConfidence := -1, 0.0
+		for i, k := range known {
+			if used[i] {
+				continue
+			}
+			m := Compare(FromCache(k, nil), ee)
+			if m.Confidence >= AutoMergeConfidence && m.Confidence > bestConfidence {
+				best, bestConfidence = i, m.Confidence
+			}
+		}
+		if best >= 0 {
+			used[best] = true
+			fillOrganization(&e, known[best])
+		}
+		res = append(res, e)
+	}
+
+	for i, k := range known {
+		if !used[i] {
+			res = append(res, k)
+		}
+	}
+
+	return res
+}
+
+// fillOrganization copies the values of src that dst does not have.
+func fillOrganization(dst *cache.Organization, src cache.Organization) {
+	fill(&dst.Description, src.Description)
+	fill(&dst.Industry, src.Industry)
+	if dst.FoundedYear == 0 {
+		dst.FoundedYear = src.FoundedYear
+	}
+
+	fill(&dst.Headquarters.Address, src.Headquarters.Address)
+	fill(&dst.Headquarters.City, src.Headquarters.City)
+	fill(&dst.Headquarters.Country, src.Headquarters.Country)
+
+	fill(&dst.Contacts.Phone, src.Contacts.Phone)
+	fill(&dst.Contacts.Email, src.Contacts.Email)
+	fill(&dst.Contacts.Website, src.Contacts.Website)
+	fill(&dst.Contacts.SocialMedia.LinkedIn, src.Contacts.SocialMedia.LinkedIn)
+	fill(&dst.Contacts.SocialMedia.Twitter, src.Contacts.SocialMedia.Twitter)
+	fill(&dst.Contacts.SocialMedia.Instagram, src.Contacts.SocialMedia.Instagram)
+	fill(&dst.Contacts.SocialMedia.Telegram, src.Contacts.SocialMedia.Telegram)
+
+	if len(dst.KeyPeople) == 0 {
+		dst.KeyPeople = src.KeyPeople
+	}
+	if len(dst.Subsidiaries) == 0 {
+		dst.Subsidiaries = src.Subsidiaries
+	}
+
+	fill(&dst.Registration.TaxID, src.Registration.TaxID)
+	fill(&dst.Registration.RegistrationNumber, src.Registration.RegistrationNumber)
+}
+
+func fill(dst *string, src string) {
+	if *dst == "" {
+		*dst = src
+	}
+}
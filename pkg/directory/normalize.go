@@ -0,0 +1,47 @@
+// Package directory holds the organization directory helpers shared by the
+// repo, the extraction pipeline and the API: name normalization and
+// conversion from the shapes the LLM and Sonar return.
+package directory
+
+import (
+	"strings"
+	"unicode"
+)
+
+// legalForms are dropped from names so "Kapitalbank ATB" and "АКБ
+// Капиталбанк" style variants compare by their distinctive part.
+var legalForms = map[string]bool{
+	"mchj": true, "aj": true, "atb": true, "akb": true, "xk": true, "qk": true,
+	"ооо": true, "ао": true, "оао": true, "зао": true, "пао": true, "ип": true, "акб": true, "атб": true, "мчж": true, "аж": true, "хк": true,
+	"ooo": true, "oao": true, "zao": true, "pao": true,
+	"llc": true, "ltd": true, "jsc": true, "inc": true, "corp": true, "plc": true,
+}
+
+// NormalizeName lowercases name, strips quotes, punctuation and legal forms
+// and collapses spaces.
+func NormalizeName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			return r
+		case r == '\'' || r == '‘' || r == '’' || r == 'ʻ' || r == 'ʼ' || r == '`':
+			// Part of Uzbek Latin letters (o‘, g‘), not a quote.
+			return -1
+		default:
+			return ' '
+		}
+	}, name)
+
+	var words []string
+	for _, w := range strings.Fields(name) {
+		if !legalForms[w] {
+			words = append(words, w)
+		}
+	}
+	if len(words) == 0 {
+		return strings.Join(strings.Fields(name), " ")
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,25 @@
+package directory
+
+import (
+	"chatbot/internal/entity"
+	"context"
+	"log/slog"
+)
+
+// Store is where the directory is persisted.
+type Store interface {
+	Upsert(ctx context.Context, req *entity.Organization) (string, error)
+}
+
+// Save upserts every organization with a name. Failures are logged, an
+// extraction is never lost because of one bad record.
+func Save(ctx context.Context, store Store, orgs []entity.Organization) {
+	for i := range orgs {
+		if orgs[i].Name == "" {
+			continue
+		}
+		if _, err := store.Upsert(ctx, &orgs[i]); err != nil {
+			slog.Error("Failed to upsert organization", "name", orgs[i].Name, "err", err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package directory
+
+import "strings"
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "j",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "x", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sh", 'ъ': "", 'ы': "i", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'ў': "o", 'қ': "q", 'ғ': "g", 'ҳ': "h",
+}
+
+// phonetic folds spellings that Uzbek Latin, Uzbek Cyrillic and Russian
+// transliterations disagree on, e.g. "Hamkorbank" / "Хамкорбанк" /
+// "Khamkorbank".
+var phonetic = strings.NewReplacer(
+	"kh", "x", "h", "x",
+	"q", "k",
+	"w", "v",
+	"ts", "s", "c", "s",
+	"yo", "e", "ye", "e", "yu", "u", "ya", "a",
+	"iy", "i", "y", "i",
+)
+
+// Transliterate converts Uzbek and Russian Cyrillic to Latin. Other runes
+// are kept.
+func Transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if l, ok := cyrillicToLatin[r]; ok {
+			b.WriteString(l)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// NameKey is the script-independent form of a name used for matching: it
+// is normalized, transliterated and phonetically folded.
+func NameKey(name string) string {
+	key := phonetic.Replace(Transliterate(NormalizeName(name)))
+
+	// Collapse doubled letters ("Kapitallbank", "Ипотека-банк").
+	var b strings.Builder
+	var prev rune
+	for _, r := range key {
+		if r != prev || r == ' ' {
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,74 @@
+// Package embedding turns stored answers and organizations into vectors and
+// finds the ones closest to a question. Vectors live in an in-process HNSW
+// index that is rebuilt from Postgres on start and kept up to date by
+// Retriever.Watch.
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"chatbot/config"
+	"chatbot/pkg/httpclient"
+)
+
+const (
+	ProviderHash   = "hash"
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+)
+
+// Embedder turns texts into vectors of the same dimension. Vectors do not
+// have to be normalized, the index does that.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// New returns the embedder selected by cfg.Embedding.Provider.
+func New(cfg *config.Config) (Embedder, error) {
+	client := httpclient.New(httpclient.Timeout(cfg.Embedding.Timeout))
+
+	switch cfg.Embedding.Provider {
+	case ProviderHash:
+		return NewHash(cfg.Embedding.Dimensions), nil
+	case ProviderGemini, "":
+		apiKey := cfg.Embedding.APIKey
+		if apiKey == "" {
+			apiKey = cfg.ApiKey.Key
+		}
+		return NewGemini(cfg.Embedding.BaseURL, apiKey, cfg.Embedding.Model, client), nil
+	case ProviderOpenAI:
+		return NewOpenAI(cfg.Embedding.BaseURL, cfg.Embedding.APIKey, cfg.Embedding.Model, client), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider: %s", cfg.Embedding.Provider)
+	}
+}
+
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+
+	norm := float32(1 / math.Sqrt(sum))
+	res := make([]float32, len(v))
+	for i, x := range v {
+		res[i] = x * norm
+	}
+	return res
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		sum += a[i] * b[i]
+	}
+	return sum
+}
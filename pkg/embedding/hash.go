@@ -0,0 +1,65 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+const _defaultDimensions = 256
+
+// Hash is a deterministic embedder built on feature hashing of words and
+// character trigrams. It needs no model, so it serves as the fake in tests
+// and local runs; its scores are too coarse to reuse answers on.
+type Hash struct {
+	dim int
+}
+
+// NewHash -.
+func NewHash(dim int) *Hash {
+	if dim <= 0 {
+		dim = _defaultDimensions
+	}
+	return &Hash{dim: dim}
+}
+
+// Embed -.
+func (h *Hash) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	res := make([][]float32, len(texts))
+	for i, t := range texts {
+		res[i] = h.embed(t)
+	}
+	return res, nil
+}
+
+func (h *Hash) embed(text string) []float32 {
+	v := make([]float32, h.dim)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		h.add(v, "w:"+w, 1)
+
+		r := []rune(" " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			h.add(v, "t:"+string(r[i:i+3]), 0.5)
+		}
+	}
+
+	return normalize(v)
+}
+
+func (h *Hash) add(v []float32, feature string, weight float32) {
+	f := fnv.New64a()
+	f.Write([]byte(feature))
+	sum := f.Sum64()
+
+	// The top bit picks the sign, so unrelated features cancel out instead
+	// of piling up.
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	v[sum%uint64(h.dim)] += weight
+}
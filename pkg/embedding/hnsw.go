@@ -0,0 +1,317 @@
+package embedding
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+const (
+	_defaultM              = 16
+	_defaultEfConstruction = 100
+	_defaultEfSearch       = 64
+
+	// _compactRatio is the share of dead nodes that has the graph rebuilt.
+	_compactRatio = 0.25
+)
+
+// Result is a stored vector close to the query. Score is the cosine
+// similarity.
+type Result struct {
+	ID    string
+	Score float32
+}
+
+// Index is an in-memory HNSW graph over normalized vectors (Malkov and
+// Yashunin). Replaced and removed vectors stay in the graph as waypoints but
+// are never returned; once they make up _compactRatio of it, the graph is
+// rebuilt without them. It is safe for concurrent use.
+type Index struct {
+	mu sync.RWMutex
+
+	m, mMax0       int
+	efConstruction int
+	efSearch       int
+	levelMult      float64
+	rng            *rand.Rand
+
+	nodes    []*node
+	ids      map[string]int
+	entry    int
+	maxLevel int
+}
+
+type node struct {
+	id      string
+	vec     []float32
+	links   [][]int
+	deleted bool
+}
+
+// NewIndex -.
+func NewIndex() *Index {
+	return &Index{
+		m:              _defaultM,
+		mMax0:          2 * _defaultM,
+		efConstruction: _defaultEfConstruction,
+		efSearch:       _defaultEfSearch,
+		levelMult:      1 / math.Log(_defaultM),
+		rng:            rand.New(rand.NewSource(1)),
+		ids:            map[string]int{},
+		entry:          -1,
+	}
+}
+
+// Len returns the number of live vectors.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.ids)
+}
+
+// Add stores vec under id, replacing what was stored under it.
+func (x *Index) Add(id string, vec []float32) {
+	vec = normalize(vec)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if old, ok := x.ids[id]; ok {
+		x.nodes[old].deleted = true
+	}
+	x.insert(id, vec)
+	x.compact()
+}
+
+// insert links a new node into the graph. The caller holds x.mu.
+func (x *Index) insert(id string, vec []float32) {
+	level := int(math.Floor(-math.Log(1-x.rng.Float64()) * x.levelMult))
+	n := &node{id: id, vec: vec, links: make([][]int, level+1)}
+	idx := len(x.nodes)
+	x.nodes = append(x.nodes, n)
+	x.ids[id] = idx
+
+	if x.entry < 0 {
+		x.entry, x.maxLevel = idx, level
+		return
+	}
+
+	ep := x.entry
+	for l := x.maxLevel; l > level; l-- {
+		ep = x.greedy(vec, ep, l)
+	}
+
+	eps := []int{ep}
+	for l := min(level, x.maxLevel); l >= 0; l-- {
+		found := x.searchLayer(vec, eps, x.efConstruction, l)
+
+		maxLinks := x.m
+		if l == 0 {
+			maxLinks = x.mMax0
+		}
+		neighbors := closest(found, x.m)
+		n.links[l] = ids(neighbors)
+
+		for _, nb := range neighbors {
+			other := x.nodes[nb.idx]
+			other.links[l] = append(other.links[l], idx)
+			if len(other.links[l]) > maxLinks {
+				other.links[l] = x.prune(other.vec, other.links[l], maxLinks)
+			}
+		}
+
+		eps = ids(found)
+	}
+
+	if level > x.maxLevel {
+		x.entry, x.maxLevel = idx, level
+	}
+}
+
+// Remove drops id from the results.
+func (x *Index) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if idx, ok := x.ids[id]; ok {
+		x.nodes[idx].deleted = true
+		delete(x.ids, id)
+		x.compact()
+	}
+}
+
+// compact rebuilds the graph from the live nodes once dead ones make up
+// _compactRatio of it. Every search walks the dead nodes, and Add would
+// keep them forever. The caller holds x.mu.
+func (x *Index) compact() {
+	dead := len(x.nodes) - len(x.ids)
+	if float64(dead) <= _compactRatio*float64(len(x.nodes)) {
+		return
+	}
+
+	nodes := x.nodes
+	x.nodes, x.ids, x.entry, x.maxLevel = nil, map[string]int{}, -1, 0
+	for _, n := range nodes {
+		if !n.deleted {
+			x.insert(n.id, n.vec)
+		}
+	}
+}
+
+// Search returns up to k live vectors closest to vec, best first.
+func (x *Index) Search(vec []float32, k int) []Result {
+	vec = normalize(vec)
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	if x.entry < 0 || k <= 0 {
+		return nil
+	}
+
+	ep := x.entry
+	for l := x.maxLevel; l > 0; l-- {
+		ep = x.greedy(vec, ep, l)
+	}
+
+	// Dead nodes take up room in the candidate list, so ask for more.
+	ef := max(x.efSearch, k) + len(x.nodes) - len(x.ids)
+	found := x.searchLayer(vec, []int{ep}, min(ef, len(x.nodes)), 0)
+
+	var res []Result
+	for _, c := range found {
+		n := x.nodes[c.idx]
+		if n.deleted {
+			continue
+		}
+		res = append(res, Result{ID: n.id, Score: 1 - c.dist})
+		if len(res) == k {
+			break
+		}
+	}
+	return res
+}
+
+type candidate struct {
+	idx  int
+	dist float32
+}
+
+func (x *Index) distance(a []float32, idx int) float32 {
+	return 1 - dot(a, x.nodes[idx].vec)
+}
+
+// greedy walks layer l towards vec and returns the closest node it reaches.
+func (x *Index) greedy(vec []float32, ep, l int) int {
+	best, bestDist := ep, x.distance(vec, ep)
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range x.nodes[best].links[l] {
+			if d := x.distance(vec, nb); d < bestDist {
+				best, bestDist, changed = nb, d, true
+			}
+		}
+	}
+	return best
+}
+
+// searchLayer returns up to ef nodes of layer l closest to vec, sorted by
+// distance.
+func (x *Index) searchLayer(vec []float32, eps []int, ef, l int) []candidate {
+	visited := map[int]bool{}
+	candidates := &minHeap{}
+	results := &maxHeap{}
+
+	for _, ep := range eps {
+		if visited[ep] {
+			continue
+		}
+		visited[ep] = true
+		c := candidate{idx: ep, dist: x.distance(vec, ep)}
+		heap.Push(candidates, c)
+		heap.Push(results, c)
+	}
+	for results.Len() > ef {
+		heap.Pop(results)
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(candidate)
+		if results.Len() >= ef && c.dist > (*results)[0].dist {
+			break
+		}
+
+		for _, nb := range x.nodes[c.idx].links[l] {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+
+			d := x.distance(vec, nb)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(candidates, candidate{idx: nb, dist: d})
+				heap.Push(results, candidate{idx: nb, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	res := []candidate(*results)
+	sort.Slice(res, func(i, j int) bool { return res[i].dist < res[j].dist })
+	return res
+}
+
+// prune keeps the n links closest to vec.
+func (x *Index) prune(vec []float32, links []int, n int) []int {
+	cs := make([]candidate, len(links))
+	for i, idx := range links {
+		cs[i] = candidate{idx: idx, dist: x.distance(vec, idx)}
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].dist < cs[j].dist })
+	return ids(cs[:n])
+}
+
+func closest(sorted []candidate, n int) []candidate {
+	if len(sorted) > n {
+		return sorted[:n]
+	}
+	return sorted
+}
+
+func ids(cs []candidate) []int {
+	res := make([]int, len(cs))
+	for i, c := range cs {
+		res[i] = c.idx
+	}
+	return res
+}
+
+type minHeap []candidate
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(v any)        { *h = append(*h, v.(candidate)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
+
+type maxHeap []candidate
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i].dist > h[j].dist }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(v any)        { *h = append(*h, v.(candidate)) }
+func (h *maxHeap) Pop() any {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
@@ -0,0 +1,188 @@
+package embedding
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func randomVectors(rng *rand.Rand, n, dim int) [][]float32 {
+	res := make([][]float32, n)
+	for i := range res {
+		v := make([]float32, dim)
+		for j := range v {
+			v[j] = float32(rng.NormFloat64())
+		}
+		res[i] = v
+	}
+	return res
+}
+
+// bruteForce returns the k live IDs closest to q.
+func bruteForce(vectors map[string][]float32, q []float32, k int) []string {
+	q = normalize(q)
+	type scored struct {
+		id    string
+		score float32
+	}
+	var all []scored
+	for id, v := range vectors {
+		all = append(all, scored{id, dot(q, normalize(v))})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].score > all[j].score })
+
+	var res []string
+	for i := 0; i < k && i < len(all); i++ {
+		res = append(res, all[i].id)
+	}
+	return res
+}
+
+// recall is the share of the exact neighbors the index finds.
+func recall(t *testing.T, x *Index, vectors map[string][]float32, queries [][]float32, k int) float64 {
+	t.Helper()
+
+	found, total := 0, 0
+	for _, q := range queries {
+		got := map[string]bool{}
+		for _, r := range x.Search(q, k) {
+			if _, ok := vectors[r.ID]; !ok {
+				t.Fatalf("Search returned %q, which is not live", r.ID)
+			}
+			got[r.ID] = true
+		}
+		for _, id := range bruteForce(vectors, q, k) {
+			total++
+			if got[id] {
+				found++
+			}
+		}
+	}
+	return float64(found) / float64(total)
+}
+
+func TestIndexRecall(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	x := NewIndex()
+
+	vectors := map[string][]float32{}
+	for i, v := range randomVectors(rng, 2000, 32) {
+		id := strconv.Itoa(i)
+		vectors[id] = v
+		x.Add(id, v)
+	}
+
+	if got := recall(t, x, vectors, randomVectors(rng, 100, 32), 10); got < 0.95 {
+		t.Errorf("recall@10 = %.3f, want at least 0.95", got)
+	}
+}
+
+func TestIndexSearchOrder(t *testing.T) {
+	x := NewIndex()
+	x.Add("a", []float32{1, 0, 0})
+	x.Add("b", []float32{1, 1, 0})
+	x.Add("c", []float32{0, 0, 1})
+
+	res := x.Search([]float32{2, 0, 0}, 3)
+	if len(res) != 3 || res[0].ID != "a" || res[1].ID != "b" || res[2].ID != "c" {
+		t.Fatalf("Search() = %+v, want a, b, c", res)
+	}
+	if res[0].Score < 0.999 {
+		t.Errorf("score of an equal direction = %v, want 1", res[0].Score)
+	}
+}
+
+func TestIndexReplaceAndRemove(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	x := NewIndex()
+
+	vectors := map[string][]float32{}
+	for i, v := range randomVectors(rng, 500, 16) {
+		id := strconv.Itoa(i)
+		vectors[id] = v
+		x.Add(id, v)
+	}
+
+	// Replace the first hundred with new vectors and remove the next
+	// hundred.
+	replaced := randomVectors(rng, 100, 16)
+	for i := 0; i < 100; i++ {
+		id := strconv.Itoa(i)
+		vectors[id] = replaced[i]
+		x.Add(id, replaced[i])
+	}
+	for i := 100; i < 200; i++ {
+		id := strconv.Itoa(i)
+		delete(vectors, id)
+		x.Remove(id)
+	}
+	x.Remove("missing")
+
+	if x.Len() != len(vectors) {
+		t.Fatalf("Len() = %d, want %d", x.Len(), len(vectors))
+	}
+
+	// A replaced vector is found by its new vector.
+	for i := 0; i < 100; i++ {
+		res := x.Search(replaced[i], 1)
+		if len(res) != 1 || res[0].ID != strconv.Itoa(i) {
+			t.Fatalf("Search(new vector of %d) = %+v", i, res)
+		}
+	}
+
+	// A search for everything returns every live vector once.
+	seen := map[string]bool{}
+	for _, r := range x.Search(replaced[0], 1000) {
+		if _, ok := vectors[r.ID]; !ok || seen[r.ID] {
+			t.Fatalf("%s returned removed or twice", r.ID)
+		}
+		seen[r.ID] = true
+	}
+	if len(seen) != len(vectors) {
+		t.Errorf("Search() returned %d, want %d", len(seen), len(vectors))
+	}
+
+	if got := recall(t, x, vectors, randomVectors(rng, 50, 16), 10); got < 0.95 {
+		t.Errorf("recall@10 after churn = %.3f, want at least 0.95", got)
+	}
+}
+
+func TestIndexCompact(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	x := NewIndex()
+
+	vectors := map[string][]float32{}
+	for round := 0; round < 20; round++ {
+		for i, v := range randomVectors(rng, 100, 16) {
+			id := strconv.Itoa(i)
+			vectors[id] = v
+			x.Add(id, v)
+		}
+
+		x.mu.RLock()
+		nodes, live := len(x.nodes), len(x.ids)
+		x.mu.RUnlock()
+		if dead := nodes - live; float64(dead) > _compactRatio*float64(nodes) {
+			t.Fatalf("round %d: %d dead of %d nodes", round, dead, nodes)
+		}
+	}
+
+	if x.Len() != 100 {
+		t.Fatalf("Len() = %d, want 100", x.Len())
+	}
+	if got := recall(t, x, vectors, randomVectors(rng, 50, 16), 10); got < 0.95 {
+		t.Errorf("recall@10 after compaction = %.3f, want at least 0.95", got)
+	}
+
+	for id := range vectors {
+		x.Remove(id)
+	}
+	if res := x.Search(randomVectors(rng, 1, 16)[0], 10); len(res) != 0 {
+		t.Errorf("Search() on an emptied index = %+v", res)
+	}
+	x.Add("a", []float32{1, 0})
+	if res := x.Search([]float32{1, 0}, 1); len(res) != 1 || res[0].ID != "a" {
+		t.Errorf("Search() after refilling = %+v", res)
+	}
+}
@@ -0,0 +1,188 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"chatbot/pkg/httpclient"
+)
+
+const (
+	defaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	defaultGeminiModel   = "text-embedding-004"
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "text-embedding-3-small"
+
+	// Both APIs limit the number of inputs per request.
+	batchSize = 100
+)
+
+// Gemini calls the Gemini batchEmbedContents REST API.
+type Gemini struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewGemini -.
+func NewGemini(baseURL, apiKey, model string, client *http.Client) *Gemini {
+	if baseURL == "" {
+		baseURL = defaultGeminiBaseURL
+	}
+	if model == "" {
+		model = defaultGeminiModel
+	}
+
+	return &Gemini{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   strings.TrimPrefix(model, "models/"),
+		client:  client,
+	}
+}
+
+// Embed -.
+func (g *Gemini) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return batched(texts, func(batch []string) ([][]float32, error) {
+		type part struct {
+			Text string `json:"text"`
+		}
+		type request struct {
+			Model   string `json:"model"`
+			Content struct {
+				Parts []part `json:"parts"`
+			} `json:"content"`
+		}
+
+		var payload struct {
+			Requests []request `json:"requests"`
+		}
+		for _, t := range batch {
+			var r request
+			r.Model = "models/" + g.model
+			r.Content.Parts = []part{{Text: t}}
+			payload.Requests = append(payload.Requests, r)
+		}
+
+		var parsed struct {
+			Embeddings []struct {
+				Values []float32 `json:"values"`
+			} `json:"embeddings"`
+		}
+		// The key goes in a header, so it does not end up in logged URLs.
+		headers := map[string]string{"x-goog-api-key": g.apiKey}
+		url := g.baseURL + "/models/" + g.model + ":batchEmbedContents"
+		if err := post(ctx, g.client, url, headers, payload, &parsed); err != nil {
+			return nil, fmt.Errorf("gemini embeddings: %w", err)
+		}
+
+		res := make([][]float32, 0, len(parsed.Embeddings))
+		for _, e := range parsed.Embeddings {
+			res = append(res, e.Values)
+		}
+		return res, nil
+	})
+}
+
+// OpenAI calls any OpenAI-compatible embeddings API.
+type OpenAI struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAI -.
+func NewOpenAI(baseURL, apiKey, model string, client *http.Client) *OpenAI {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+
+	return &OpenAI{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  client,
+	}
+}
+
+// Embed -.
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return batched(texts, func(batch []string) ([][]float32, error) {
+		payload := map[string]any{
+			"model": o.model,
+			"input": batch,
+		}
+
+		var parsed struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			} `json:"data"`
+		}
+		headers := map[string]string{"Authorization": "Bearer " + o.apiKey}
+		if err := post(ctx, o.client, o.baseURL+"/embeddings", headers, payload, &parsed); err != nil {
+			return nil, fmt.Errorf("openai embeddings: %w", err)
+		}
+
+		res := make([][]float32, len(batch))
+		for _, d := range parsed.Data {
+			if d.Index >= 0 && d.Index < len(res) {
+				res[d.Index] = d.Embedding
+			}
+		}
+		return res, nil
+	})
+}
+
+func batched(texts []string, fn func(batch []string) ([][]float32, error)) ([][]float32, error) {
+	res := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+
+		vectors, err := fn(texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != end-start {
+			return nil, fmt.Errorf("got %d embeddings for %d texts", len(vectors), end-start)
+		}
+		res = append(res, vectors...)
+	}
+	return res, nil
+}
+
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if err := httpclient.CheckStatus(resp); err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,179 @@
+package embedding
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"chatbot/internal/entity"
+)
+
+const _syncBatch = 200
+
+// Source returns the snippets updated at or after since, oldest first.
+type Source func(ctx context.Context, since time.Time, limit int) ([]entity.Snippet, error)
+
+// Hit is a snippet found for a query.
+type Hit struct {
+	Snippet entity.Snippet
+	Score   float32
+}
+
+// Retriever keeps the index in step with its sources and answers queries.
+type Retriever struct {
+	embedder Embedder
+	index    *Index
+	sources  []Source
+
+	syncMu sync.Mutex
+
+	mu       sync.RWMutex
+	snippets map[string]entity.Snippet
+	since    []time.Time
+}
+
+// NewRetriever -.
+func NewRetriever(embedder Embedder, sources ...Source) *Retriever {
+	return &Retriever{
+		embedder: embedder,
+		index:    NewIndex(),
+		sources:  sources,
+		snippets: map[string]entity.Snippet{},
+		since:    make([]time.Time, len(sources)),
+	}
+}
+
+// Len returns the number of indexed snippets.
+func (r *Retriever) Len() int {
+	return r.index.Len()
+}
+
+// Sync indexes what the sources changed since the last sync.
+func (r *Retriever) Sync(ctx context.Context) error {
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+
+	for i, source := range r.sources {
+		for {
+			batch, err := source(ctx, r.since[i], _syncBatch)
+			if err != nil {
+				return err
+			}
+			if err := r.Add(ctx, batch); err != nil {
+				return err
+			}
+			if len(batch) == 0 {
+				break
+			}
+
+			last := batch[len(batch)-1].UpdatedAt
+			if len(batch) < _syncBatch {
+				r.since[i] = last.Add(time.Microsecond)
+				break
+			}
+
+			// Rows sharing the last timestamp may go on in the next batch,
+			// so it starts from that timestamp; the index replaces what it
+			// reads twice. A full batch of one timestamp would loop.
+			if !last.After(r.since[i]) {
+				last = r.since[i].Add(time.Microsecond)
+			}
+			r.since[i] = last
+		}
+	}
+
+	return nil
+}
+
+// Watch syncs every interval until ctx is done.
+func (r *Retriever) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Sync(ctx); err != nil {
+				slog.Error("Semantic index sync failed", "err", err)
+			}
+		}
+	}
+}
+
+// Add indexes snippets, dropping the deleted ones.
+func (r *Retriever) Add(ctx context.Context, snippets []entity.Snippet) error {
+	var texts []string
+	var live []entity.Snippet
+	for _, s := range snippets {
+		if s.Deleted {
+			r.index.Remove(key(s))
+			r.mu.Lock()
+			delete(r.snippets, key(s))
+			r.mu.Unlock()
+			continue
+		}
+		texts = append(texts, s.Title+"\n"+s.Text)
+		live = append(live, s)
+	}
+	if len(live) == 0 {
+		return nil
+	}
+
+	vectors, err := r.embedder.Embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, s := range live {
+		r.snippets[key(s)] = s
+		r.index.Add(key(s), vectors[i])
+	}
+
+	return nil
+}
+
+// Search returns up to k snippets scoring at least minScore, best first.
+// Given kinds, only snippets of those kinds are returned.
+func (r *Retriever) Search(ctx context.Context, query string, k int, minScore float32, kinds ...string) ([]Hit, error) {
+	if r.index.Len() == 0 {
+		return nil, nil
+	}
+
+	vectors, err := r.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Other kinds take up room in the results, so ask for more.
+	n := k
+	if len(kinds) > 0 {
+		n = k * 4
+	}
+
+	var hits []Hit
+	for _, res := range r.index.Search(vectors[0], n) {
+		if res.Score < minScore || len(hits) == k {
+			break
+		}
+		s, ok := r.snippets[res.ID]
+		if !ok || (len(kinds) > 0 && !slices.Contains(kinds, s.Kind)) {
+			continue
+		}
+		hits = append(hits, Hit{Snippet: s, Score: res.Score})
+	}
+
+	return hits, nil
+}
+
+func key(s entity.Snippet) string {
+	return s.Kind + ":" + s.ID
+}
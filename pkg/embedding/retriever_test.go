@@ -0,0 +1,186 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"chatbot/internal/entity"
+)
+
+// fakeSource serves snippets the way the repos do: updated at or after
+// since, oldest first.
+type fakeSource struct {
+	mu       sync.Mutex
+	snippets map[string]entity.Snippet
+	calls    []time.Time
+}
+
+func (s *fakeSource) put(sn entity.Snippet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.snippets == nil {
+		s.snippets = map[string]entity.Snippet{}
+	}
+	s.snippets[sn.ID] = sn
+}
+
+func (s *fakeSource) get(ctx context.Context, since time.Time, limit int) ([]entity.Snippet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, since)
+
+	var res []entity.Snippet
+	for _, sn := range s.snippets {
+		if !sn.UpdatedAt.Before(since) {
+			res = append(res, sn)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].UpdatedAt.Equal(res[j].UpdatedAt) {
+			return res[i].UpdatedAt.Before(res[j].UpdatedAt)
+		}
+		return res[i].ID < res[j].ID
+	})
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (s *fakeSource) lastSince() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[len(s.calls)-1]
+}
+
+func answer(id, text string, at time.Time) entity.Snippet {
+	return entity.Snippet{ID: id, Kind: entity.SnippetAnswer, Title: text, Text: text, UpdatedAt: at}
+}
+
+func TestRetrieverSync(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	source := &fakeSource{}
+	source.put(answer("1", "Hamkorbank manzili Andijon", base))
+	source.put(answer("2", "Kapitalbank telefon raqami", base.Add(time.Second)))
+
+	r := NewRetriever(NewHash(0), source.get)
+	if err := r.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", r.Len())
+	}
+
+	// The next sync starts right after the newest snippet it has seen.
+	if err := r.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := source.lastSince(), base.Add(time.Second+time.Microsecond); !got.Equal(want) {
+		t.Errorf("second sync since %v, want %v", got, want)
+	}
+
+	// An edit replaces the snippet, a deletion drops it.
+	source.put(answer("1", "Ipoteka bank ish vaqti", base.Add(time.Minute)))
+	deleted := answer("2", "Kapitalbank telefon raqami", base.Add(time.Minute))
+	deleted.Deleted = true
+	source.put(deleted)
+	if err := r.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := r.Search(ctx, "Ipoteka bank ish vaqti", 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Snippet.ID != "1" || hits[0].Snippet.Text != "Ipoteka bank ish vaqti" {
+		t.Fatalf("Search() = %+v, want the edited snippet only", hits)
+	}
+	if hits, _ := r.Search(ctx, "Kapitalbank telefon raqami", 5, 0.9); len(hits) != 0 {
+		t.Errorf("Search() = %+v, want the deleted snippet gone", hits)
+	}
+}
+
+// Snippets sharing a timestamp across a batch boundary are all indexed.
+func TestRetrieverSyncBatchBoundary(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	source := &fakeSource{}
+	n := _syncBatch + 50
+	for i := 0; i < n; i++ {
+		at := base.Add(time.Duration(i) * time.Second)
+		if i >= _syncBatch-10 && i < _syncBatch+10 {
+			at = base.Add(time.Hour)
+		}
+		source.put(answer(fmt.Sprintf("%03d", i), fmt.Sprintf("javob %d", i), at))
+	}
+
+	r := NewRetriever(NewHash(0), source.get)
+	if err := r.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != n {
+		t.Errorf("Len() = %d, want %d", r.Len(), n)
+	}
+}
+
+// A full batch of one timestamp moves the cursor on instead of reading the
+// same batch forever.
+func TestRetrieverSyncFullBatchOfOneTimestamp(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	source := &fakeSource{}
+	for i := 0; i < _syncBatch; i++ {
+		source.put(answer(fmt.Sprintf("%03d", i), fmt.Sprintf("javob %d", i), at))
+	}
+	source.put(answer("later", "keyingi javob", at.Add(time.Second)))
+
+	r := NewRetriever(NewHash(0), source.get)
+	done := make(chan error, 1)
+	go func() { done <- r.Sync(ctx) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Sync did not return")
+	}
+	if r.Len() != _syncBatch+1 {
+		t.Errorf("Len() = %d, want %d", r.Len(), _syncBatch+1)
+	}
+}
+
+func TestRetrieverSearchKinds(t *testing.T) {
+	ctx := context.Background()
+	at := time.Now()
+
+	r := NewRetriever(NewHash(0))
+	err := r.Add(ctx, []entity.Snippet{
+		answer("1", "Hamkorbank manzili", at),
+		{ID: "1", Kind: entity.SnippetDocument, Title: "Hamkorbank manzili", Text: "Andijon", UpdatedAt: at},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same ID of another kind is another snippet.
+	if r.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", r.Len())
+	}
+	hits, err := r.Search(ctx, "Hamkorbank manzili", 5, 0, entity.SnippetDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Snippet.Kind != entity.SnippetDocument {
+		t.Errorf("Search(documents) = %+v", hits)
+	}
+}
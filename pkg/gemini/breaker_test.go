@@ -0,0 +1,60 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakerCallerCancel(t *testing.T) {
+	s := NewService(NewFakeProvider(), Breaker(3, time.Hour))
+	fail := func(ctx context.Context) error { return errors.New("unavailable") }
+	cancelled := func(ctx context.Context) error { return ctx.Err() }
+
+	for range 2 {
+		if err := s.call(context.Background(), fail); err == nil {
+			t.Fatal("call() error = nil, want the provider error")
+		}
+	}
+
+	// A caller giving up in the middle of an outage does not reset the
+	// failures: the next failure opens the breaker.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.call(ctx, cancelled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("call() error = %v, want %v", err, context.Canceled)
+	}
+	_ = s.call(context.Background(), fail)
+
+	if err := s.call(context.Background(), fail); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("call() error = %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestBreakerCancelledTrial(t *testing.T) {
+	s := NewService(NewFakeProvider(), Breaker(1, time.Millisecond))
+	ok := func(ctx context.Context) error { return nil }
+	_ = s.call(context.Background(), func(ctx context.Context) error { return errors.New("unavailable") })
+	time.Sleep(2 * time.Millisecond)
+
+	// The trial call is cancelled: the breaker stays open and the next
+	// call after the cooldown is a trial again.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = s.call(ctx, func(ctx context.Context) error { return ctx.Err() })
+
+	s.breaker.mu.Lock()
+	failures, trial := s.breaker.failures, s.breaker.trial
+	s.breaker.mu.Unlock()
+	if failures != 1 || trial {
+		t.Fatalf("after a cancelled trial failures = %d, trial = %v, want 1, false", failures, trial)
+	}
+
+	if err := s.call(context.Background(), ok); err != nil {
+		t.Errorf("trial call() error = %v", err)
+	}
+	if err := s.call(context.Background(), ok); err != nil {
+		t.Errorf("call() after a good trial error = %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package gemini
+
+import (
+	"chatbot/internal/entity"
+	"chatbot/pkg/cache"
+	"context"
+	"strings"
+)
+
+const fakeSummaryPrefix = "Earlier the user asked: "
+
+var (
+	fakeGreetings = []string{"salom", "assalomu", "hello", "hi", "привет", "здравствуйте", "салом"}
+	fakeMultiple  = []string{"top", "biggest", "list", "eng katta", "ro'yxat", "ro‘yxat", "самые", "список"}
+)
+
+// FakeProvider is a deterministic provider for local runs and tests. It
+// never calls the network.
+type FakeProvider struct{}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (p *FakeProvider) Route(ctx context.Context, req RouteRequest) (*GeminiResponse, error) {
+	q := strings.ToLower(strings.TrimSpace(req.Question))
+
+	for _, g := range fakeGreetings {
+		if strings.HasPrefix(q, g) {
+			return &GeminiResponse{
+				Route:       "gemini",
+				Explanation: "Salom! Men O‘zbekistondagi tashkilotlar haqida ma’lumot beraman.",
+			}, nil
+		}
+	}
+
+	enriched := strings.TrimSpace(req.Question)
+	if len(req.Organizations) > 0 && len(strings.Fields(q)) <= 3 {
+		enriched = req.Organizations[0].Name + ": " + enriched
+	}
+
+	multiple := false
+	for _, m := range fakeMultiple {
+		if strings.Contains(q, m) {
+			multiple = true
+			break
+		}
+	}
+
+	return &GeminiResponse{
+		Route:           RouteSonar,
+		EnrichedQuery:   enriched + " (Uzbekistan)",
+		ExpectsMultiple: multiple,
+	}, nil
+}
+
+func (p *FakeProvider) ExtractOrganizations(ctx context.Context, sonarResp string) ([]cache.Organization, error) {
+	name := strings.TrimSpace(strings.SplitN(sonarResp, "\n", 2)[0])
+	if len(name) > 100 {
+		name = name[:100]
+	}
+
+	var org cache.Organization
+	org.Name = name
+	org.Description = sonarResp
+	org.Headquarters.Country = "Uzbekistan"
+
+	return []cache.Organization{org}, nil
+}
+
+// Summarize keeps the last five user questions, newest first.
+func (p *FakeProvider) Summarize(ctx context.Context, summary string, turns []entity.Turn) (string, error) {
+	var questions []string
+	for i := len(turns) - 1; i >= 0; i-- {
+		if turns[i].Role == entity.RoleUser {
+			questions = append(questions, turns[i].Text)
+		}
+	}
+	if summary != "" {
+		questions = append(questions, strings.Split(strings.TrimPrefix(summary, fakeSummaryPrefix), "; ")...)
+	}
+	if len(questions) > 5 {
+		questions = questions[:5]
+	}
+
+	return fakeSummaryPrefix + strings.Join(questions, "; "), nil
+}
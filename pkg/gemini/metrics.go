@@ -0,0 +1,13 @@
+package gemini
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// routeResults counts router calls by outcome: ok, repaired, repair_failed
+// and provider_error.
+var routeResults = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "llm_route_results_total",
+	Help: "Router calls by provider and outcome.",
+}, []string{"provider", "outcome"})
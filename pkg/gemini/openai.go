@@ -0,0 +1,147 @@
+package gemini
+
+import (
+	"bytes"
+	"chatbot/internal/entity"
+	"chatbot/pkg/cache"
+	"chatbot/pkg/httpclient"
+	"chatbot/pkg/prompt"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "gpt-4o-mini"
+)
+
+// OpenAIProvider talks to any OpenAI-compatible chat completions API.
+type OpenAIProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+	prompts *prompt.Registry
+}
+
+func NewOpenAIProvider(baseURL, apiKey, model string, client *http.Client, prompts *prompt.Registry) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	if client == nil {
+		client = httpclient.New(httpclient.Timeout(0))
+	}
+
+	return &OpenAIProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  client,
+		prompts: prompts,
+	}
+}
+
+func (p *OpenAIProvider) Route(ctx context.Context, req RouteRequest) (*GeminiResponse, error) {
+	send := func(ctx context.Context, prompt string) (string, error) {
+		return p.send(ctx, prompt, map[string]any{
+			"type": "json_schema",
+			"json_schema": map[string]any{
+				"name":   "route",
+				"schema": routeSchema,
+			},
+		})
+	}
+
+	return route(ctx, ProviderOpenAI, send, p.prompts, req)
+}
+
+func (p *OpenAIProvider) ExtractOrganizations(ctx context.Context, sonarResp string) ([]cache.Organization, error) {
+	// The extraction prompt asks for a top-level array, which the structured
+	// output modes do not accept, so it is left in plain text mode.
+	text, err := buildOrganizationPrompt(p.prompts, sonarResp)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := p.send(ctx, text, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed []cache.Organization
+	if err := json.Unmarshal([]byte(cleanJSON(raw)), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAI response as JSON: %w", err)
+	}
+
+	return parsed, nil
+}
+
+func (p *OpenAIProvider) Summarize(ctx context.Context, summary string, turns []entity.Turn) (string, error) {
+	text, err := buildSummaryPrompt(p.prompts, summary, turns)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := p.send(ctx, text, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(raw), nil
+}
+
+func (p *OpenAIProvider) send(ctx context.Context, prompt string, responseFormat map[string]any) (string, error) {
+	payload := map[string]any{
+		"model": p.model,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+	}
+	if responseFormat != nil {
+		payload["response_format"] = responseFormat
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader([]byte(mustJSON(payload))))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send message to OpenAI: %w", err)
+	}
+	if err := httpclient.CheckStatus(resp); err != nil {
+		return "", fmt.Errorf("openai: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var parsed struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", err
+	}
+	if len(parsed.Choices) == 0 {
+		return "", fmt.Errorf("no choices returned from OpenAI")
+	}
+
+	return parsed.Choices[0].Message.Content, nil
+}
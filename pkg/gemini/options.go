@@ -0,0 +1,20 @@
+package gemini
+
+import "time"
+
+// Option -.
+type Option func(*Service)
+
+// Timeout -.
+func Timeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.timeout = timeout
+	}
+}
+
+// Breaker -.
+func Breaker(threshold int, cooldown time.Duration) Option {
+	return func(s *Service) {
+		s.breaker = newBreaker(threshold, cooldown)
+	}
+}
@@ -0,0 +1,99 @@
+package gemini
+
+import (
+	"chatbot/internal/entity"
+	"chatbot/pkg/lang"
+	"chatbot/pkg/prompt"
+	"encoding/json"
+	"strings"
+)
+
+type routerData struct {
+	History       string
+	Organizations string
+	Question      string
+	Language      string
+	Grounding     string
+	Summary       string
+}
+
+type summaryData struct {
+	Summary string
+	History string
+}
+
+type organizationData struct {
+	SonarResponse string
+}
+
+func init() {
+	prompt.Register(prompt.Router, routerData{
+		History:       "- User: Hamkorbank qayerda?",
+		Organizations: "[]",
+		Question:      "Hamkorbank telefon raqami",
+		Language:      "Uzbek",
+		Grounding:     "Hamkorbank: Andijon, Bobur shoh ko‘chasi 85",
+		Summary:       "The user asks about banks.",
+	})
+	prompt.Register(prompt.Summary, summaryData{
+		Summary: "The user asks about banks.",
+		History: "- User: Hamkorbank qayerda?",
+	})
+	prompt.Register(prompt.OrganizationMerge, organizationData{
+		SonarResponse: "Hamkorbank is a commercial bank in Andijan.",
+	})
+}
+
+func buildRouterPrompt(prompts *prompt.Registry, req RouteRequest) (string, int, error) {
+	return prompts.Render(prompt.Router, routerData{
+		History:       formatHistory(req.History),
+		Organizations: mustJSON(req.Organizations),
+		Question:      req.Question,
+		Language:      lang.Name(req.Language),
+		Grounding:     strings.Join(req.Grounding, "\n"),
+		Summary:       req.Summary,
+	})
+}
+
+// formatHistory writes one "- User: ..." or "- Assistant: ..." line per turn.
+func formatHistory(turns []entity.Turn) string {
+	lines := make([]string, 0, len(turns))
+	for _, t := range turns {
+		speaker := "User"
+		if t.Role == entity.RoleAssistant {
+			speaker = "Assistant"
+		}
+		text := strings.Join(strings.Fields(t.Text), " ")
+		lines = append(lines, "- "+speaker+": "+text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func buildSummaryPrompt(prompts *prompt.Registry, summary string, turns []entity.Turn) (string, error) {
+	text, _, err := prompts.Render(prompt.Summary, summaryData{
+		Summary: summary,
+		History: formatHistory(turns),
+	})
+	return text, err
+}
+
+func buildOrganizationPrompt(prompts *prompt.Registry, sonarResp string) (string, error) {
+	text, _, err := prompts.Render(prompt.OrganizationMerge, organizationData{
+		SonarResponse: sonarResp,
+	})
+	return text, err
+}
+
+// cleanJSON strips markdown fences and a leading "json" tag that models like
+// to wrap around their answers.
+func cleanJSON(raw string) string {
+	clean := strings.Trim(strings.TrimSpace(raw), "` \n")
+	clean = strings.TrimPrefix(clean, "json")
+	clean = strings.TrimPrefix(clean, "JSON")
+	return clean
+}
+
+func mustJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,68 @@
+package gemini
+
+import (
+	"chatbot/config"
+	"chatbot/internal/entity"
+	"chatbot/pkg/cache"
+	"chatbot/pkg/httpclient"
+	"chatbot/pkg/prompt"
+	"context"
+	"fmt"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+	ProviderFake   = "fake"
+)
+
+// RouteRequest is everything the router needs to classify one question.
+type RouteRequest struct {
+	Question      string
+	Language      string
+	Organizations []cache.Organization
+
+	// History is the conversation so far, oldest first. Summary covers
+	// what came before it.
+	History []entity.Turn
+	Summary string
+
+	// Grounding are stored snippets relevant to the question.
+	Grounding []string
+}
+
+// LLMProvider is the model behind the chat gateway. Route classifies the user
+// question and enriches it for Sonar in a single call, ExtractOrganizations
+// pulls the organizations out of a Sonar answer, Summarize folds turns into
+// the running summary of a conversation.
+type LLMProvider interface {
+	Route(ctx context.Context, req RouteRequest) (*GeminiResponse, error)
+	ExtractOrganizations(ctx context.Context, sonarResp string) ([]cache.Organization, error)
+	Summarize(ctx context.Context, summary string, turns []entity.Turn) (string, error)
+}
+
+// NewProvider returns the provider selected by cfg.LLM.Provider. The Gemini
+// provider reuses the given client, other providers ignore it.
+func NewProvider(cfg *config.Config, client *genai.Client, prompts *prompt.Registry) (LLMProvider, error) {
+	switch cfg.LLM.Provider {
+	case ProviderGemini, "":
+		if client == nil {
+			return nil, fmt.Errorf("gemini provider requires a client")
+		}
+		return NewGeminiProvider(client, cfg.LLM.Model, cfg.LLM.Retries, prompts), nil
+	case ProviderOpenAI:
+		// Deadlines come from the context given by Service, not from the client.
+		client := httpclient.New(
+			httpclient.Timeout(0),
+			httpclient.Retries(cfg.LLM.Retries),
+			httpclient.PoolSize(cfg.LLM.PoolSize),
+		)
+		return NewOpenAIProvider(cfg.LLM.BaseURL, cfg.LLM.APIKey, cfg.LLM.Model, client, prompts), nil
+	case ProviderFake:
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown llm provider: %s", cfg.LLM.Provider)
+	}
+}
@@ -0,0 +1,115 @@
+package gemini
+
+import (
+	"chatbot/pkg/prompt"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	RouteGemini = "gemini"
+	RouteSonar  = "sonar"
+)
+
+// routeSchema is the structured output contract of the router call. Both
+// the Gemini and the OpenAI providers translate it to their own schema type.
+var routeSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"route": map[string]any{
+			"type": "string",
+			"enum": []string{RouteGemini, RouteSonar},
+		},
+		"explanation":      map[string]string{"type": "string"},
+		"enriched_query":   map[string]string{"type": "string"},
+		"expects_multiple": map[string]string{"type": "boolean"},
+	},
+	"required": []string{"route"},
+}
+
+const repairPrompt = `
+Your previous answer did not match the required JSON schema.
+
+Error: %s
+
+Previous answer:
+%s
+
+Return the corrected answer as a single JSON object with the fields "route" ("gemini" or "sonar"), "explanation", "enriched_query" and "expects_multiple".
+If "route" is "gemini", "explanation" must not be empty.
+If "route" is "sonar", "enriched_query" must not be empty.
+Do not add any text outside of the JSON object.
+`
+
+// route renders the router prompt, sends it through send and validates the
+// answer. On a schema violation it retries once with the router prompt
+// followed by the repair prompt.
+func route(ctx context.Context, provider string, send func(ctx context.Context, prompt string) (string, error), prompts *prompt.Registry, req RouteRequest) (*GeminiResponse, error) {
+	text, version, err := buildRouterPrompt(prompts, req)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := send(ctx, text)
+	if err != nil {
+		routeResults.WithLabelValues(provider, "provider_error").Inc()
+		return nil, err
+	}
+
+	parsed, err := parseRoute(raw)
+	if err == nil {
+		routeResults.WithLabelValues(provider, "ok").Inc()
+		parsed.PromptVersion = version
+		return parsed, nil
+	}
+
+	// The model has no memory of the first call, so the repair repeats it.
+	raw, err = send(ctx, text+fmt.Sprintf(repairPrompt, err.Error(), raw))
+	if err != nil {
+		routeResults.WithLabelValues(provider, "provider_error").Inc()
+		return nil, err
+	}
+
+	parsed, err = parseRoute(raw)
+	if err != nil {
+		routeResults.WithLabelValues(provider, "repair_failed").Inc()
+		return nil, fmt.Errorf("router answer violates schema after repair: %w", err)
+	}
+
+	routeResults.WithLabelValues(provider, "repaired").Inc()
+	parsed.PromptVersion = version
+	return parsed, nil
+}
+
+func parseRoute(raw string) (*GeminiResponse, error) {
+	var parsed GeminiResponse
+	if err := json.Unmarshal([]byte(cleanJSON(raw)), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if err := parsed.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
+
+// Validate checks the fields required by the chosen route.
+func (r *GeminiResponse) Validate() error {
+	switch r.Route {
+	case RouteGemini:
+		if r.Explanation == "" {
+			return errors.New(`"explanation" is required when "route" is "gemini"`)
+		}
+	case RouteSonar:
+		if r.EnrichedQuery == "" {
+			return errors.New(`"enriched_query" is required when "route" is "sonar"`)
+		}
+	default:
+		return fmt.Errorf(`"route" must be "gemini" or "sonar", got %q`, r.Route)
+	}
+
+	return nil
+}
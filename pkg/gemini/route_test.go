@@ -0,0 +1,157 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"chatbot/pkg/prompt"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeSend answers the router calls in turn and records the prompts. A nil
+// answer is the one FakeProvider gives.
+type fakeSend struct {
+	t       *testing.T
+	req     RouteRequest
+	answers []*string
+	err     error
+	prompts []string
+}
+
+func (f *fakeSend) send(ctx context.Context, text string) (string, error) {
+	f.prompts = append(f.prompts, text)
+	if f.err != nil {
+		return "", f.err
+	}
+	if len(f.answers) == 0 {
+		f.t.Fatalf("unexpected router call %d", len(f.prompts))
+	}
+
+	answer := f.answers[0]
+	f.answers = f.answers[1:]
+	if answer != nil {
+		return *answer, nil
+	}
+
+	res, err := NewFakeProvider().Route(ctx, f.req)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	raw, err := json.Marshal(res)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return "```json\n" + string(raw) + "\n```", nil
+}
+
+func text(s string) *string {
+	return &s
+}
+
+func testPrompts(t *testing.T) *prompt.Registry {
+	t.Helper()
+	prompts := prompt.New("../../prompts", nil)
+	if err := prompts.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return prompts
+}
+
+func TestRoute(t *testing.T) {
+	prompts := testPrompts(t)
+	req := RouteRequest{Question: "Hamkorbank manzili qayerda", Language: "uz"}
+	rendered, _, err := buildRouterPrompt(prompts, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		answers []*string
+		err     error
+		calls   int
+		result  string
+		wantErr string
+	}{
+		{
+			name:    "valid answer",
+			answers: []*string{nil},
+			calls:   1,
+			result:  "ok",
+		},
+		{
+			name:    "schema violation repaired",
+			answers: []*string{text(`{"route":"sonar"}`), nil},
+			calls:   2,
+			result:  "repaired",
+		},
+		{
+			name:    "invalid JSON repaired",
+			answers: []*string{text(`route: sonar`), nil},
+			calls:   2,
+			result:  "repaired",
+		},
+		{
+			name:    "repair fails",
+			answers: []*string{text(`{"route":"web"}`), text(`{"route":"gemini"}`)},
+			calls:   2,
+			result:  "repair_failed",
+			wantErr: "after repair",
+		},
+		{
+			name:    "provider error",
+			err:     errors.New("unavailable"),
+			calls:   1,
+			result:  "provider_error",
+			wantErr: "unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeSend{t: t, req: req, answers: tt.answers, err: tt.err}
+			counter := routeResults.WithLabelValues(ProviderFake, tt.result)
+			before := testutil.ToFloat64(counter)
+
+			res, err := route(context.Background(), ProviderFake, f.send, prompts, req)
+
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("route results %q += %v, want 1", tt.result, got)
+			}
+			if len(f.prompts) != tt.calls {
+				t.Fatalf("got %d router calls, want %d", len(f.prompts), tt.calls)
+			}
+			if f.prompts[0] != rendered {
+				t.Errorf("first call is not the router prompt")
+			}
+			if tt.calls > 1 {
+				// The repair call stands on its own: the router prompt, why
+				// the answer was rejected and the answer itself.
+				repair := f.prompts[1]
+				if !strings.HasPrefix(repair, rendered) {
+					t.Errorf("repair call does not start with the router prompt:\n%s", repair)
+				}
+				if !strings.Contains(repair, "did not match the required JSON schema") || !strings.Contains(repair, *tt.answers[0]) {
+					t.Errorf("repair call lacks the error or the previous answer:\n%s", repair)
+				}
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("route() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("route() error = %v", err)
+			}
+			if res.Route != RouteSonar || res.EnrichedQuery != "Hamkorbank manzili qayerda (Uzbekistan)" {
+				t.Errorf("route() = %+v", res)
+			}
+		})
+	}
+}
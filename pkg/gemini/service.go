@@ -0,0 +1,135 @@
+package gemini
+
+import (
+	"chatbot/config"
+	"chatbot/internal/entity"
+	"chatbot/pkg/prompt"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+const (
+	_defaultTimeout          = 30 * time.Second
+	_defaultBreakerThreshold = 5
+	_defaultBreakerCooldown  = 30 * time.Second
+)
+
+// Service is the long-lived entry point to the LLM. It is created once in
+// app.Run, owns the shared client and is safe for concurrent use.
+type Service struct {
+	provider LLMProvider
+	client   *genai.Client
+	timeout  time.Duration
+	breaker  *breaker
+}
+
+// New builds the provider selected in cfg together with its shared client.
+func New(ctx context.Context, cfg *config.Config, prompts *prompt.Registry) (*Service, error) {
+	var client *genai.Client
+	if cfg.LLM.Provider == ProviderGemini || cfg.LLM.Provider == "" {
+		var err error
+		client, err = genai.NewClient(ctx,
+			option.WithAPIKey(cfg.ApiKey.Key),
+			option.WithGRPCConnectionPool(cfg.LLM.PoolSize),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("gemini - New - genai.NewClient: %w", err)
+		}
+	}
+
+	provider, err := NewProvider(cfg, client, prompts)
+	if err != nil {
+		if client != nil {
+			client.Close()
+		}
+		return nil, err
+	}
+
+	s := NewService(provider,
+		Timeout(cfg.LLM.Timeout),
+		Breaker(cfg.LLM.BreakerThreshold, cfg.LLM.BreakerCooldown),
+	)
+	s.client = client
+
+	return s, nil
+}
+
+// NewService wraps an already built provider, e.g. the fake one.
+func NewService(provider LLMProvider, opts ...Option) *Service {
+	s := &Service{
+		provider: provider,
+		timeout:  _defaultTimeout,
+		breaker:  newBreaker(_defaultBreakerThreshold, _defaultBreakerCooldown),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Close -.
+func (s *Service) Close() {
+	if s.client != nil {
+		s.client.Close()
+	}
+}
+
+// GetResponse asks the provider whether the question goes to Gemini or
+// Sonar. It returns nil when the provider fails.
+func (s *Service) GetResponse(ctx context.Context, req RouteRequest) *GeminiResponse {
+	var parsed *GeminiResponse
+	err := s.call(ctx, func(ctx context.Context) error {
+		var err error
+		parsed, err = s.provider.Route(ctx, req)
+		return err
+	})
+	if err != nil {
+		slog.Error("failed to get routing decision", "error", err)
+		return nil
+	}
+
+	return parsed
+}
+
+// call runs fn under the per-call timeout and the circuit breaker. The
+// deadline is derived from ctx, so a caller that gives up earlier wins.
+func (s *Service) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := s.breaker.allow(); err != nil {
+		return err
+	}
+
+	callCtx := ctx
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	err := fn(callCtx)
+	if err != nil && ctx.Err() != nil {
+		// The caller went away, that says nothing about the provider.
+		s.breaker.release()
+		return err
+	}
+	s.breaker.done(err)
+
+	return err
+}
+
+// Summarize folds turns into the running summary of a conversation.
+func (s *Service) Summarize(ctx context.Context, summary string, turns []entity.Turn) (string, error) {
+	var res string
+	err := s.call(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = s.provider.Summarize(ctx, summary, turns)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,146 @@
+// Package httpclient builds the HTTP client shared by outbound provider
+// calls: a per-provider timeout and jittered retries on 429 and 5xx that
+// honor Retry-After.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	_defaultTimeout       = 30 * time.Second
+	_defaultRetries       = 2
+	_defaultMinBackoff    = 500 * time.Millisecond
+	_defaultMaxBackoff    = 5 * time.Second
+	_defaultMaxRetryAfter = 30 * time.Second
+)
+
+// StatusError is a non 2xx answer from an upstream.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("upstream returned status %d: %s", e.Code, e.Body)
+}
+
+// New returns a client whose transport retries transient failures. The
+// timeout covers the whole call, retries included.
+func New(opts ...Option) *http.Client {
+	t := &retryTransport{
+		base:          http.DefaultTransport.(*http.Transport).Clone(),
+		retries:       _defaultRetries,
+		minBackoff:    _defaultMinBackoff,
+		maxBackoff:    _defaultMaxBackoff,
+		maxRetryAfter: _defaultMaxRetryAfter,
+	}
+	c := &http.Client{
+		Transport: t,
+		Timeout:   _defaultTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(c, t)
+	}
+
+	return c
+}
+
+// CheckStatus turns a non 2xx response into a *StatusError. The body is
+// closed in that case.
+func CheckStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+
+	return &StatusError{Code: resp.StatusCode, Body: string(body)}
+}
+
+// RetryableStatus reports whether an answer with this status may succeed
+// when sent again.
+func RetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// Retryable reports whether err is a transient upstream failure.
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return RetryableStatus(statusErr.Code)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// Backoff returns the full-jitter delay before retry number attempt
+// (starting at 0). It never exceeds maxDelay.
+func Backoff(attempt int, minDelay, maxDelay time.Duration) time.Duration {
+	d := minDelay << attempt
+	if d <= 0 || d > maxDelay {
+		d = maxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return min(minDelay/2+time.Duration(rand.Int63n(int64(d))), maxDelay)
+}
+
+// RetryAfter parses the Retry-After header, given in seconds or as a date.
+func RetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// Wait sleeps for d or until ctx is done.
+func Wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,155 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryResendsBody(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := New(Retries(2), BackoffRange(time.Millisecond, 2*time.Millisecond))
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{"q":"salom"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body
+
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("status %d after %d calls, want 200 after 3", resp.StatusCode, calls.Load())
+	}
+	for i, b := range bodies {
+		if b != `{"q":"salom"}` {
+			t.Errorf("attempt %d sent %q", i, b)
+		}
+	}
+	if req.Body != body {
+		t.Error("the caller's request body was replaced")
+	}
+}
+
+func TestNoRetryWithoutGetBody(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New(Retries(2), BackoffRange(time.Millisecond, 2*time.Millisecond))
+	req, err := http.NewRequest(http.MethodPost, srv.URL, io.NopCloser(strings.NewReader("once")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("status %d after %d calls, want 503 after 1", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryConnectionRefused(t *testing.T) {
+	// A port nothing listens on.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	var attempts atomic.Int32
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	c := New(Retries(2), BackoffRange(time.Millisecond, 2*time.Millisecond))
+	c.Transport.(*retryTransport).base = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		attempts.Add(1)
+		return base.RoundTrip(r)
+	})
+
+	_, err = c.Get("http://" + addr)
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Fatalf("Get() error = %v, want connection refused", err)
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("%d attempts, want 3", attempts.Load())
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&StatusError{Code: 429}, true},
+		{&StatusError{Code: 502}, true},
+		{&StatusError{Code: 400}, false},
+		{fmt.Errorf("wrapped: %w", &StatusError{Code: 503}), true},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{io.ErrUnexpectedEOF, true},
+		{&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true},
+		{errors.New("invalid API key"), false},
+	}
+
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	minDelay, maxDelay := 500*time.Millisecond, 5*time.Second
+
+	for attempt := 0; attempt < 10; attempt++ {
+		for i := 0; i < 1000; i++ {
+			d := Backoff(attempt, minDelay, maxDelay)
+			if d < minDelay/2 || d > maxDelay {
+				t.Fatalf("Backoff(%d) = %v, want within [%v, %v]", attempt, d, minDelay/2, maxDelay)
+			}
+		}
+	}
+	if d := Backoff(3, 0, 0); d != 0 {
+		t.Errorf("Backoff without a range = %v, want 0", d)
+	}
+}
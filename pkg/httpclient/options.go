@@ -0,0 +1,47 @@
+package httpclient
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option -.
+type Option func(*http.Client, *retryTransport)
+
+// Timeout -.
+func Timeout(timeout time.Duration) Option {
+	return func(c *http.Client, _ *retryTransport) {
+		c.Timeout = timeout
+	}
+}
+
+// Retries -.
+func Retries(retries int) Option {
+	return func(_ *http.Client, t *retryTransport) {
+		t.retries = retries
+	}
+}
+
+// BackoffRange -.
+func BackoffRange(min, max time.Duration) Option {
+	return func(_ *http.Client, t *retryTransport) {
+		t.minBackoff = min
+		t.maxBackoff = max
+	}
+}
+
+// MaxRetryAfter -.
+func MaxRetryAfter(d time.Duration) Option {
+	return func(_ *http.Client, t *retryTransport) {
+		t.maxRetryAfter = d
+	}
+}
+
+// PoolSize -.
+func PoolSize(size int) Option {
+	return func(_ *http.Client, t *retryTransport) {
+		if base, ok := t.base.(*http.Transport); ok && size > 0 {
+			base.MaxIdleConnsPerHost = size
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type retryTransport struct {
+	base          http.RoundTripper
+	retries       int
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	maxRetryAfter time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	hasBody := req.Body != nil && req.Body != http.NoBody
+
+	retries := t.retries
+	if hasBody && req.GetBody == nil {
+		// The body can not be sent twice.
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		// The caller's request is not to be modified, so every attempt
+		// sends a copy with a body of its own.
+		r := req.Clone(req.Context())
+		if attempt > 0 && hasBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(r)
+		if attempt >= retries || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if !Retryable(err) {
+				return nil, err
+			}
+			wait = Backoff(attempt, t.minBackoff, t.maxBackoff)
+		case RetryableStatus(resp.StatusCode):
+			wait = Backoff(attempt, t.minBackoff, t.maxBackoff)
+			if d, ok := RetryAfter(resp.Header, time.Now()); ok {
+				if d > t.maxRetryAfter {
+					// Waiting that long is worse than failing now.
+					return resp, nil
+				}
+				wait = d
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		slog.Warn("Retrying upstream request", "url", req.URL.Host+req.URL.Path, "attempt", attempt+1, "wait", wait, "err", err)
+
+		if err := Wait(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
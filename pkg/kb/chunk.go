@@ -0,0 +1,65 @@
+package kb
+
+import "strings"
+
+const (
+	_defaultChunkSize    = 1000
+	_defaultChunkOverlap = 150
+)
+
+// Chunk splits text into pieces of about size runes that overlap by about
+// overlap runes. Pieces end at paragraph, line or sentence breaks where
+// possible, so a fact is not cut in half.
+func Chunk(text string, size, overlap int) []string {
+	if size <= 0 {
+		size = _defaultChunkSize
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = _defaultChunkOverlap
+		if overlap >= size {
+			overlap = size / 5
+		}
+	}
+
+	r := []rune(strings.TrimSpace(text))
+	var chunks []string
+
+	for start := 0; start < len(r); {
+		end := start + size
+		if end >= len(r) {
+			chunks = append(chunks, strings.TrimSpace(string(r[start:])))
+			break
+		}
+		end = breakPoint(r, start+size/2, end)
+
+		chunks = append(chunks, strings.TrimSpace(string(r[start:end])))
+
+		// A piece can end as early as half its size, so the overlap is
+		// held to half of what was cut; the next piece always moves on.
+		next := max(end-overlap, start+(end-start+1)/2)
+		// The overlap starts at a word, not in the middle of one.
+		for next < end && r[next-1] != ' ' && r[next-1] != '\n' {
+			next++
+		}
+		if next <= start || next >= end {
+			next = end
+		}
+		start = next
+	}
+
+	return chunks
+}
+
+// breakPoint returns the best place to cut r in [min, max]: after a blank
+// line, else a line break, else a sentence end, else a space, else max.
+func breakPoint(r []rune, min, max int) int {
+	for _, sep := range []string{"\n\n", "\n", ". ", " "} {
+		s := []rune(sep)
+		for i := max - len(s); i >= min; i-- {
+			if string(r[i:i+len(s)]) == sep {
+				return i + len(s)
+			}
+		}
+	}
+	return max
+}
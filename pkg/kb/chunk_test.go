@@ -0,0 +1,89 @@
+package kb
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunk(t *testing.T) {
+	words := strings.Repeat("Bank filiali har kuni soat 9 dan 18 gacha ishlaydi. ", 60)
+
+	tests := []struct {
+		name          string
+		text          string
+		size, overlap int
+	}{
+		{name: "short text", text: "Bir jumla.", size: 1000, overlap: 150},
+		{name: "sentences", text: words, size: 300, overlap: 50},
+		{name: "paragraphs", text: strings.Repeat("Birinchi band.\n\nIkkinchi band matni.\n", 80), size: 200, overlap: 40},
+		{name: "no breaks", text: strings.Repeat("x", 2500), size: 1000, overlap: 150},
+		// The early paragraph break used to push the overlap before the
+		// start of the piece.
+		{name: "overlap past early break", text: strings.Repeat("a", 500) + "\n\n" + strings.Repeat("b", 800), size: 1000, overlap: 600},
+		{name: "overlap past early break with words", text: strings.Repeat("so‘z ", 100) + "\n\n" + strings.Repeat("matn ", 160), size: 1000, overlap: 900},
+		{name: "overlap not below size", text: words, size: 100, overlap: 100},
+		{name: "negative overlap", text: words, size: 200, overlap: -1},
+		{name: "default size", text: words, size: 0, overlap: 0},
+		{name: "cyrillic", text: strings.Repeat("Банк ҳар куни ишлайди. ", 100), size: 120, overlap: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := Chunk(tt.text, tt.size, tt.overlap)
+			if len(chunks) == 0 {
+				t.Fatal("Chunk() returned no chunks")
+			}
+
+			size := tt.size
+			if size <= 0 {
+				size = _defaultChunkSize
+			}
+			for i, c := range chunks {
+				if c == "" {
+					t.Errorf("chunk %d is empty", i)
+				}
+				if n := utf8.RuneCountInString(c); n > size {
+					t.Errorf("chunk %d has %d runes, want at most %d", i, n, size)
+				}
+			}
+
+			// Every word that fits in a chunk ends up in one.
+			joined := strings.Join(chunks, " ")
+			for _, w := range strings.Fields(tt.text) {
+				if utf8.RuneCountInString(w) < size && !strings.Contains(joined, w) {
+					t.Fatalf("word %q is missing from the chunks", w)
+				}
+			}
+			if !strings.HasSuffix(chunks[len(chunks)-1], strings.TrimSpace(tt.text)[len(strings.TrimSpace(tt.text))-5:]) {
+				t.Errorf("last chunk %q does not end the text", chunks[len(chunks)-1])
+			}
+		})
+	}
+}
+
+func TestChunkBreaksAtParagraph(t *testing.T) {
+	first := strings.Repeat("a", 600)
+	text := first + "\n\n" + strings.Repeat("b", 600)
+
+	chunks := Chunk(text, 1000, 100)
+	if chunks[0] != first {
+		t.Errorf("first chunk = %q..., want the first paragraph", chunks[0][:20])
+	}
+}
+
+func TestChunkOverlap(t *testing.T) {
+	text := strings.Repeat("alpha beta gamma delta. ", 50)
+
+	chunks := Chunk(text, 200, 50)
+	if len(chunks) < 2 {
+		t.Fatalf("Chunk() = %d chunks, want several", len(chunks))
+	}
+	for i := 1; i < len(chunks); i++ {
+		prev := chunks[i-1]
+		head := strings.Fields(chunks[i])[0]
+		if !strings.Contains(prev[len(prev)/2:], head) {
+			t.Errorf("chunk %d does not start inside the end of chunk %d", i, i-1)
+		}
+	}
+}
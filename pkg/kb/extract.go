@@ -0,0 +1,82 @@
+// Package kb turns knowledge base documents uploaded by admins into plain
+// text chunks for the semantic index.
+package kb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnsupported is returned for file types Extract cannot read.
+	ErrUnsupported = errors.New("unsupported document type")
+
+	// ErrNoText is returned when a document has no extractable text, e.g.
+	// a scanned PDF.
+	ErrNoText = errors.New("document has no extractable text")
+)
+
+// Extensions -.
+var Extensions = map[string]string{
+	".txt":  "text/plain",
+	".md":   "text/markdown",
+	".pdf":  "application/pdf",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+// Extract returns the text of a document, choosing the reader by the
+// extension of name.
+func Extract(name string, data []byte) (string, error) {
+	var (
+		text string
+		err  error
+	)
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".txt", ".md":
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("%w: text is not UTF-8", ErrUnsupported)
+		}
+		text = string(data)
+	case ".pdf":
+		text, err = extractPDF(data)
+	case ".docx":
+		text, err = extractDOCX(data)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, filepath.Ext(name))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	text = cleanText(text)
+	if text == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+// cleanText trims lines and collapses runs of blank lines.
+func cleanText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			if !blank && len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		lines = append(lines, line)
+		blank = false
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,150 @@
+package kb
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		want string
+		err  error
+	}{
+		{
+			name: "text",
+			file: "faq.txt",
+			data: []byte("  Filial   soat 9 da ochiladi.  \r\n\r\n\r\n\r\nShanba dam olish kuni.\n"),
+			want: "Filial soat 9 da ochiladi.\n\nShanba dam olish kuni.",
+		},
+		{
+			name: "markdown upper case extension",
+			file: "README.MD",
+			data: []byte("# Tariflar\n\nKarta xizmati bepul."),
+			want: "# Tariflar\n\nKarta xizmati bepul.",
+		},
+		{
+			name: "text not utf-8",
+			file: "faq.txt",
+			data: []byte{'a', 0xff, 0xfe, 'b'},
+			err:  ErrUnsupported,
+		},
+		{
+			name: "unknown extension",
+			file: "tariffs.xlsx",
+			data: []byte("PK"),
+			err:  ErrUnsupported,
+		},
+		{
+			name: "empty text",
+			file: "empty.txt",
+			data: []byte(" \n\t\n "),
+			err:  ErrNoText,
+		},
+		{
+			name: "docx",
+			file: "rules.docx",
+			data: docx(t, `<w:p><w:r><w:t>Kredit</w:t></w:r><w:r><w:tab/><w:t>shartlari</w:t></w:r></w:p>`+
+				`<w:p><w:r><w:t>Foiz stavkasi 24%.</w:t></w:r></w:p>`),
+			want: "Kredit shartlari\n\nFoiz stavkasi 24%.",
+		},
+		{
+			name: "docx without text",
+			file: "blank.docx",
+			data: docx(t, `<w:p></w:p>`),
+			err:  ErrNoText,
+		},
+		{
+			name: "pdf",
+			file: "rules.pdf",
+			data: pdf(t, false, "BT /F1 12 Tf 72 712 Td (Omonat shartlari) Tj 0 -14 Td [(Yillik) -250 (18%)] TJ ET"),
+			want: "Omonat shartlari\nYillik 18%",
+		},
+		{
+			name: "pdf flate",
+			file: "rules.pdf",
+			data: pdf(t, true, "BT /F1 12 Tf 72 712 Td (Mijozlar \\(jismoniy shaxslar\\)) Tj ET"),
+			want: "Mijozlar (jismoniy shaxslar)",
+		},
+		{
+			name: "pdf utf-16",
+			file: "rules.pdf",
+			data: pdf(t, false, "BT <FEFF041A0430044004420430> Tj ET"),
+			want: "Карта",
+		},
+		{
+			name: "scanned pdf",
+			file: "scan.pdf",
+			data: pdf(t, false, "q 612 0 0 792 0 0 cm /Im1 Do Q"),
+			err:  ErrNoText,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract(tt.file, tt.data)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Extract(%q) error = %v, want %v", tt.file, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Extract(%q) error = %v", tt.file, err)
+			}
+			if got != tt.want {
+				t.Errorf("Extract(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+// docx builds a document with body as the content of w:body.
+func docx(t *testing.T, body string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>`+
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>%s</w:body></w:document>`, body)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pdf builds a one page document drawing content.
+func pdf(t *testing.T, flate bool, content string) []byte {
+	t.Helper()
+
+	stream, filter := []byte(content), ""
+	if flate {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(stream)
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		stream, filter = buf.Bytes(), " /Filter /FlateDecode"
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	b.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	b.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n")
+	b.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R >> endobj\n")
+	fmt.Fprintf(&b, "4 0 obj << /Length %d%s >>\nstream\n", len(stream), filter)
+	b.Write(stream)
+	b.WriteString("\nendstream\nendobj\ntrailer << /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
@@ -0,0 +1,291 @@
+package kb
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// extractPDF pulls the text drawn by the content streams of a PDF. It
+// handles uncompressed and Flate streams and single-byte or UTF-16 strings,
+// which covers PDFs exported from office suites. Fonts with custom CID maps
+// and scanned pages yield no text and end up as ErrNoText.
+func extractPDF(data []byte) (string, error) {
+	var b strings.Builder
+
+	for _, stream := range pdfStreams(data) {
+		if !bytes.Contains(stream, []byte("BT")) {
+			continue
+		}
+		b.WriteString(pdfContentText(stream))
+		b.WriteString("\n\n")
+	}
+
+	text := b.String()
+	if !mostlyPrintable(text) {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+// pdfStreams returns the decoded streams of data, skipping the ones in
+// filters other than Flate (images, fonts).
+func pdfStreams(data []byte) [][]byte {
+	var res [][]byte
+
+	for pos := 0; ; {
+		i := bytes.Index(data[pos:], []byte("stream"))
+		if i < 0 {
+			break
+		}
+		start := pos + i
+		pos = start + len("stream")
+
+		if start >= 3 && string(data[start-3:start]) == "end" {
+			continue
+		}
+
+		body := pos
+		if body < len(data) && data[body] == '\r' {
+			body++
+		}
+		if body < len(data) && data[body] == '\n' {
+			body++
+		}
+
+		end := bytes.Index(data[body:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		raw := data[body : body+end]
+		pos = body + end + len("endstream")
+
+		dictStart := bytes.LastIndex(data[:start], []byte("obj"))
+		if dictStart < 0 {
+			dictStart = 0
+		}
+		dict := string(data[dictStart:start])
+
+		switch {
+		case strings.Contains(dict, "/FlateDecode"):
+			zr, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			// Truncated streams still give their readable start.
+			decoded, _ := io.ReadAll(zr)
+			zr.Close()
+			res = append(res, decoded)
+		case strings.Contains(dict, "/Filter"):
+			continue
+		default:
+			res = append(res, raw)
+		}
+	}
+
+	return res
+}
+
+// pdfContentText interprets the text operators of a content stream.
+func pdfContentText(content []byte) string {
+	var b strings.Builder
+	var operands []string
+	var array []string
+	inArray := false
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, n := pdfLiteral(content[i:])
+			i += n
+			if inArray {
+				array = append(array, s)
+			} else {
+				operands = append(operands, s)
+			}
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return b.String()
+			}
+			s := pdfHex(content[i+1 : i+end])
+			i += end + 1
+			if inArray {
+				array = append(array, s)
+			} else {
+				operands = append(operands, s)
+			}
+		case c == '[':
+			inArray, array = true, nil
+			i++
+		case c == ']':
+			inArray = false
+			operands = append(operands, strings.Join(array, ""))
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case isPDFSpace(c):
+			i++
+		default:
+			j := i
+			for j < len(content) && !isPDFSpace(content[j]) && !strings.ContainsRune("()<>[]/%", rune(content[j])) {
+				j++
+			}
+			if j == i {
+				// A name or a delimiter we do not care about.
+				j++
+			}
+			word := string(content[i:j])
+			i = j
+
+			if inArray {
+				// A large negative kerning inside TJ is a word gap.
+				if n, err := strconv.ParseFloat(word, 64); err == nil && n < -200 {
+					array = append(array, " ")
+				}
+				continue
+			}
+
+			switch word {
+			case "Tj", "TJ":
+				if len(operands) > 0 {
+					b.WriteString(operands[len(operands)-1])
+				}
+			case "'", `"`:
+				b.WriteString("\n")
+				if len(operands) > 0 {
+					b.WriteString(operands[len(operands)-1])
+				}
+			case "Td", "TD", "T*", "Tm":
+				b.WriteString("\n")
+			case "ET":
+				b.WriteString("\n")
+			}
+			if _, err := strconv.ParseFloat(word, 64); err != nil {
+				operands = operands[:0]
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// pdfLiteral reads a (...) string and returns it with the bytes consumed.
+func pdfLiteral(data []byte) (string, int) {
+	var out []byte
+	depth := 0
+	i := 0
+
+	for i < len(data) {
+		c := data[i]
+		switch {
+		case c == '\\' && i+1 < len(data):
+			i++
+			switch e := data[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// A line continuation.
+			default:
+				if e >= '0' && e <= '7' {
+					j := i
+					for j < len(data) && j < i+3 && data[j] >= '0' && data[j] <= '7' {
+						j++
+					}
+					n, _ := strconv.ParseUint(string(data[i:j]), 8, 8)
+					out = append(out, byte(n))
+					i = j - 1
+				} else {
+					out = append(out, e)
+				}
+			}
+		case c == '(':
+			depth++
+			if depth > 1 {
+				out = append(out, c)
+			}
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return pdfDecode(out), i + 1
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+		i++
+	}
+
+	return pdfDecode(out), i
+}
+
+func pdfHex(hex []byte) string {
+	var digits []byte
+	for _, c := range hex {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	out := make([]byte, 0, len(digits)/2)
+	for i := 0; i+1 < len(digits); i += 2 {
+		n, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return ""
+		}
+		out = append(out, byte(n))
+	}
+	return pdfDecode(out)
+}
+
+// pdfDecode reads UTF-16BE strings by their byte order mark and treats the
+// rest as Latin-1, which matches WinAnsi and PDFDocEncoding for text.
+func pdfDecode(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		u := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+// mostlyPrintable rejects the glyph IDs that CID fonts leave behind.
+func mostlyPrintable(text string) bool {
+	total, good := 0, 0
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		total++
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsPunct(r) {
+			good++
+		}
+	}
+	return total > 0 && float64(good)/float64(total) > 0.8
+}
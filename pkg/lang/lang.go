@@ -0,0 +1,126 @@
+// Package lang detects the language of a user question and holds the canned
+// messages we send back in that language.
+package lang
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	UzLatn = "uz-Latn"
+	UzCyrl = "uz-Cyrl"
+	Ru     = "ru"
+	En     = "en"
+
+	Default = UzLatn
+)
+
+var names = map[string]string{
+	UzLatn: "Uzbek (Latin script)",
+	UzCyrl: "Uzbek (Cyrillic script)",
+	Ru:     "Russian",
+	En:     "English",
+}
+
+var (
+	uzCyrlLetters = "ўқғҳЎҚҒҲ"
+	uzCyrlWords   = []string{"ва", "учун", "қайси", "нима", "қаерда", "қандай", "ҳақида", "борми", "манзили", "рақами"}
+	uzLatnWords   = []string{"va", "uchun", "qaysi", "nima", "qayerda", "qanday", "haqida", "bormi", "manzil", "manzili", "raqami", "salom", "assalomu", "kerak", "eng", "bilan", "qancha", "menga", "ber", "tashkilot", "tashkilotlar"}
+	enWords       = []string{"the", "what", "where", "is", "are", "how", "of", "in", "phone", "address", "number", "hello", "hi", "which", "list", "about", "biggest", "top", "me", "please"}
+)
+
+// Detect guesses the language of text. It returns an empty string when the
+// text gives no clear signal, e.g. a bare organization name.
+func Detect(text string) string {
+	var cyr, lat int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyr++
+		case unicode.Is(unicode.Latin, r):
+			lat++
+		}
+	}
+	if cyr == 0 && lat == 0 {
+		return ""
+	}
+
+	lower := strings.ToLower(text)
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\'' && r != '‘' && r != '’'
+	})
+
+	if cyr > lat {
+		if strings.ContainsAny(text, uzCyrlLetters) || countWords(words, uzCyrlWords) > 0 {
+			return UzCyrl
+		}
+		return Ru
+	}
+
+	uz := countWords(words, uzLatnWords)
+	if strings.ContainsAny(lower, "‘’'ʻ") && (strings.Contains(lower, "o‘") || strings.Contains(lower, "g‘") ||
+		strings.Contains(lower, "o'") || strings.Contains(lower, "g'") || strings.Contains(lower, "oʻ") || strings.Contains(lower, "gʻ")) {
+		uz += 2
+	}
+	en := countWords(words, enWords)
+
+	switch {
+	case uz > en:
+		return UzLatn
+	case en > uz:
+		return En
+	default:
+		return ""
+	}
+}
+
+// Resolve returns the detected language of text, falling back to the stored
+// user language and then to Default.
+func Resolve(text, stored string) string {
+	if l := Detect(text); l != "" {
+		return l
+	}
+	if l := Normalize(stored); l != "" {
+		return l
+	}
+	return Default
+}
+
+// Normalize maps the values kept in users.language to one of the supported
+// tags. Unknown values give an empty string.
+func Normalize(code string) string {
+	switch strings.ToLower(strings.TrimSpace(code)) {
+	case "uz", "uz-latn", "uz_latn", "oz":
+		return UzLatn
+	case "uz-cyrl", "uz_cyrl", "cyrl":
+		return UzCyrl
+	case "ru":
+		return Ru
+	case "en":
+		return En
+	default:
+		return ""
+	}
+}
+
+// Name is the English name of the language, used inside prompts.
+func Name(code string) string {
+	if n, ok := names[code]; ok {
+		return n
+	}
+	return names[Default]
+}
+
+func countWords(words, dict []string) int {
+	n := 0
+	for _, w := range words {
+		for _, d := range dict {
+			if w == d {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
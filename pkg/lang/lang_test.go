@@ -0,0 +1,119 @@
+package lang
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "uzbek latin", text: "Hamkorbank manzili qayerda", want: UzLatn},
+		{name: "uzbek latin by o‘ and g‘", text: "Toshkentdagi o‘quv markazlari", want: UzLatn},
+		{name: "uzbek cyrillic by letters", text: "Ҳамкорбанк қаерда жойлашган", want: UzCyrl},
+		{name: "uzbek cyrillic by words", text: "банк манзили", want: UzCyrl},
+		{name: "russian", text: "Где находится Хамкорбанк?", want: Ru},
+		{name: "english", text: "What is the phone number of Hamkorbank", want: En},
+		{name: "mixed scripts, more cyrillic", text: "Hamkorbank где находится офис", want: Ru},
+		{name: "mixed languages, more uzbek", text: "Hamkorbank address qayerda va qanday boraman", want: UzLatn},
+		{name: "bare name", text: "Hamkorbank", want: ""},
+		{name: "as many uzbek as english words", text: "Hamkorbank phone raqami", want: ""},
+		{name: "no letters", text: "+998 71 200-00-00", want: ""},
+		{name: "empty", text: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.text); got != tt.want {
+				t.Errorf("Detect(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		text, stored, want string
+	}{
+		{"Где находится Хамкорбанк?", "uz", Ru},
+		{"Hamkorbank", "ru", Ru},
+		{"Hamkorbank", "uz_cyrl", UzCyrl},
+		{"Hamkorbank", "de", Default},
+		{"", "", Default},
+	}
+
+	for _, tt := range tests {
+		if got := Resolve(tt.text, tt.stored); got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", tt.text, tt.stored, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		code, want string
+	}{
+		{"uz", UzLatn},
+		{" UZ-Latn ", UzLatn},
+		{"oz", UzLatn},
+		{"uz-Cyrl", UzCyrl},
+		{"cyrl", UzCyrl},
+		{"RU", Ru},
+		{"en", En},
+		{"de", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.code); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		name, code, key, want string
+	}{
+		{name: "uzbek latin", code: UzLatn, key: DailyLimit, want: "kunlik limit tugadi"},
+		{name: "uzbek cyrillic", code: UzCyrl, key: DailyLimit, want: "кунлик лимит тугади"},
+		{name: "russian", code: Ru, key: Phone, want: "Телефон"},
+		{name: "english", code: En, key: AnswerFailed, want: "could not prepare an answer, please send the question again"},
+		{name: "unknown language falls back to default", code: "de", key: Website, want: "Sayt"},
+		{name: "empty language falls back to default", code: "", key: Address, want: "Manzil"},
+		{name: "unknown key is returned as is", code: Ru, key: "no_such_key", want: "no_such_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := T(tt.code, tt.key); got != tt.want {
+				t.Errorf("T(%q, %q) = %q, want %q", tt.code, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatalogComplete(t *testing.T) {
+	for key, m := range messages {
+		for code := range names {
+			if m[code] == "" {
+				t.Errorf("message %q has no %s text", key, code)
+			}
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	var err error = NewError(Ru, GuestLimit)
+
+	var langErr *Error
+	if !errors.As(err, &langErr) || langErr.Key != GuestLimit {
+		t.Fatalf("errors.As(%v) = %+v, want key %q", err, langErr, GuestLimit)
+	}
+	if want := "ваши 3 бесплатных запроса закончились, зарегистрируйтесь, чтобы продолжить"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
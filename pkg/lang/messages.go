@@ -0,0 +1,92 @@
+package lang
+
+const (
+	GuestLimit = "guest_limit"
+	DailyLimit = "daily_limit"
+
+	SearchUnavailable = "search_unavailable"
+	AnswerFailed      = "answer_failed"
+
+	Address = "address"
+	Phone   = "phone"
+	Email   = "email"
+	Website = "website"
+)
+
+var messages = map[string]map[string]string{
+	GuestLimit: {
+		UzLatn: "sizning 3 ta bepul so‘rovingiz tugadi, davom etish uchun ro‘yxatdan o‘ting",
+		UzCyrl: "сизнинг 3 та бепул сўровингиз тугади, давом этиш учун рўйхатдан ўтинг",
+		Ru:     "ваши 3 бесплатных запроса закончились, зарегистрируйтесь, чтобы продолжить",
+		En:     "your 3 free requests are used up, sign up to continue",
+	},
+	DailyLimit: {
+		UzLatn: "kunlik limit tugadi",
+		UzCyrl: "кунлик лимит тугади",
+		Ru:     "дневной лимит исчерпан",
+		En:     "daily limit reached",
+	},
+	SearchUnavailable: {
+		UzLatn: "qidiruv xizmati vaqtincha ishlamayapti, birozdan so‘ng qayta urinib ko‘ring",
+		UzCyrl: "қидирув хизмати вақтинча ишламаяпти, бироздан сўнг қайта уриниб кўринг",
+		Ru:     "сервис поиска временно недоступен, попробуйте ещё раз чуть позже",
+		En:     "search is temporarily unavailable, please try again in a moment",
+	},
+	AnswerFailed: {
+		UzLatn: "javob tayyorlab bo‘lmadi, savolni qayta yuboring",
+		UzCyrl: "жавоб тайёрлаб бўлмади, саволни қайта юборинг",
+		Ru:     "не удалось подготовить ответ, отправьте вопрос ещё раз",
+		En:     "could not prepare an answer, please send the question again",
+	},
+	Address: {
+		UzLatn: "Manzil",
+		UzCyrl: "Манзил",
+		Ru:     "Адрес",
+		En:     "Address",
+	},
+	Phone: {
+		UzLatn: "Telefon",
+		UzCyrl: "Телефон",
+		Ru:     "Телефон",
+		En:     "Phone",
+	},
+	Email: {
+		UzLatn: "E-pochta",
+		UzCyrl: "Э-почта",
+		Ru:     "Эл. почта",
+		En:     "Email",
+	},
+	Website: {
+		UzLatn: "Sayt",
+		UzCyrl: "Сайт",
+		Ru:     "Сайт",
+		En:     "Website",
+	},
+}
+
+// T returns the message for key in the given language.
+func T(code, key string) string {
+	m, ok := messages[key]
+	if !ok {
+		return key
+	}
+	if s, ok := m[code]; ok {
+		return s
+	}
+	return m[Default]
+}
+
+// Error is a user facing error that renders in the language it was created
+// with.
+type Error struct {
+	Key  string
+	Lang string
+}
+
+func NewError(code, key string) *Error {
+	return &Error{Key: key, Lang: code}
+}
+
+func (e *Error) Error() string {
+	return T(e.Lang, e.Key)
+}
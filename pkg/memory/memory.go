@@ -0,0 +1,302 @@
+// Package memory keeps the context of each chat room for the models: a
+// rolling summary of the older exchanges and the recent turns. Postgres
+// holds both, Redis caches them for every active room.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"chatbot/config"
+	"chatbot/internal/entity"
+	"chatbot/internal/usecase"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ConversationMemory serves conversation windows. It wraps the chat repo so
+// every saved exchange also lands in the cached window of its room.
+type ConversationMemory struct {
+	usecase.ChatRepoI
+
+	redis        *redis.Client
+	maxExchanges int
+	tokenBudget  int
+	ttl          time.Duration
+}
+
+// New -.
+func New(repo usecase.ChatRepoI, rdb *redis.Client, cfg *config.Config) *ConversationMemory {
+	return &ConversationMemory{
+		ChatRepoI:    repo,
+		redis:        rdb,
+		maxExchanges: cfg.Memory.MaxExchanges,
+		tokenBudget:  cfg.Memory.TokenBudget,
+		ttl:          cfg.Memory.CacheTTL,
+	}
+}
+
+// Window returns the summary of a chat room and its recent turns, oldest
+// first, cut to the token budget. The summary gets at most half of it.
+func (m *ConversationMemory) Window(ctx context.Context, chatRoomID string) (*entity.Conversation, error) {
+	summary, err := m.summary(ctx, chatRoomID)
+	if err != nil {
+		return nil, err
+	}
+	turns, err := m.load(ctx, chatRoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.tokenBudget <= 0 {
+		return &entity.Conversation{Summary: summary, Turns: turns}, nil
+	}
+	summary = truncate(summary, max(m.tokenBudget/2, 1))
+	return &entity.Conversation{
+		Summary: summary,
+		Turns:   Trim(turns, m.tokenBudget-Tokens(summary)),
+	}, nil
+}
+
+// Create saves an exchange and appends it to the cached window.
+func (m *ConversationMemory) Create(ctx context.Context, req *entity.ChatCreate) error {
+	if err := m.ChatRepoI.Create(ctx, req); err != nil {
+		return err
+	}
+	m.append(ctx, req.ChatRoomID, Turns(req, time.Now()))
+	return nil
+}
+
+// DeleteChatRoom deletes a chat room and forgets its window.
+func (m *ConversationMemory) DeleteChatRoom(ctx context.Context, id *entity.ById) error {
+	if err := m.ChatRepoI.DeleteChatRoom(ctx, id); err != nil {
+		return err
+	}
+	if err := m.redis.Del(ctx, cacheKey(id.Id), summaryKey(id.Id)).Err(); err != nil {
+		slog.Warn("Failed to drop conversation cache", "chat_room_id", id.Id, "err", err)
+	}
+	return nil
+}
+
+// summary reads the summary of a chat room through the cache. A room
+// without one caches the empty string.
+func (m *ConversationMemory) summary(ctx context.Context, chatRoomID string) (string, error) {
+	key := summaryKey(chatRoomID)
+
+	cached, err := m.redis.Get(ctx, key).Result()
+	if err == nil {
+		return cached, nil
+	}
+	if err != redis.Nil {
+		slog.Warn("Failed to read summary cache", "chat_room_id", chatRoomID, "err", err)
+	}
+
+	res, err := m.ChatRepoI.GetSummary(ctx, chatRoomID)
+	if err != nil {
+		return "", err
+	}
+	if err := m.redis.Set(ctx, key, res.Text, m.ttl).Err(); err != nil {
+		slog.Warn("Failed to cache summary", "chat_room_id", chatRoomID, "err", err)
+	}
+
+	return res.Text, nil
+}
+
+// SaveSummary stores a summary and drops the cached one.
+func (m *ConversationMemory) SaveSummary(ctx context.Context, chatRoomID, summary string, exchanges int) error {
+	if err := m.ChatRepoI.SaveSummary(ctx, chatRoomID, summary, exchanges); err != nil {
+		return err
+	}
+	if err := m.redis.Del(ctx, summaryKey(chatRoomID)).Err(); err != nil {
+		slog.Warn("Failed to drop summary cache", "chat_room_id", chatRoomID, "err", err)
+	}
+	return nil
+}
+
+// load reads the window from Redis, or from Postgres on a miss and caches
+// it. A broken cache is skipped, never fatal.
+func (m *ConversationMemory) load(ctx context.Context, chatRoomID string) ([]entity.Turn, error) {
+	key := cacheKey(chatRoomID)
+
+	cached, err := m.redis.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		slog.Warn("Failed to read conversation cache", "chat_room_id", chatRoomID, "err", err)
+	}
+	if len(cached) > 0 {
+		turns, err := decode(cached)
+		if err == nil {
+			return turns, nil
+		}
+		slog.Warn("Invalid conversation cache", "chat_room_id", chatRoomID, "err", err)
+	}
+
+	turns, err := m.ChatRepoI.GetRecentTurns(ctx, chatRoomID, m.maxExchanges)
+	if err != nil {
+		return nil, err
+	}
+	if len(turns) == 0 {
+		return nil, nil
+	}
+
+	values, err := encode(turns)
+	if err != nil {
+		return nil, err
+	}
+	pipe := m.redis.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.RPush(ctx, key, values...)
+	pipe.Expire(ctx, key, m.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Warn("Failed to cache conversation", "chat_room_id", chatRoomID, "err", err)
+	}
+
+	return turns, nil
+}
+
+// append adds turns to a cached window. A room that is not cached is left
+// alone: its next read loads the full window from Postgres.
+func (m *ConversationMemory) append(ctx context.Context, chatRoomID string, turns []entity.Turn) {
+	key := cacheKey(chatRoomID)
+
+	n, err := m.redis.Exists(ctx, key).Result()
+	if err != nil || n == 0 || len(turns) == 0 {
+		return
+	}
+
+	values, err := encode(turns)
+	if err != nil {
+		return
+	}
+	pipe := m.redis.TxPipeline()
+	pipe.RPush(ctx, key, values...)
+	pipe.LTrim(ctx, key, int64(-2*m.maxExchanges), -1)
+	pipe.Expire(ctx, key, m.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Warn("Failed to append to conversation cache", "chat_room_id", chatRoomID, "err", err)
+	}
+}
+
+// Turns returns the user and assistant turns of a saved exchange. A list
+// answer has no text, its turn names the organizations instead.
+func Turns(req *entity.ChatCreate, at time.Time) []entity.Turn {
+	var res []entity.Turn
+	if req.UserRequest != "" {
+		res = append(res, entity.Turn{Role: entity.RoleUser, Text: req.UserRequest, CreatedAt: at})
+	}
+
+	answer := req.Responce
+	if answer == "" {
+		if names := organizationNames(req.Organizations); len(names) > 0 {
+			answer = "Organizations: " + strings.Join(names, ", ")
+		}
+	}
+	if answer != "" {
+		res = append(res, entity.Turn{Role: entity.RoleAssistant, Text: answer, CreatedAt: at})
+	}
+
+	return res
+}
+
+// Trim keeps the most recent turns that fit in budget tokens. A turn longer
+// than a quarter of the budget is cut, so one long answer does not crowd out
+// the rest of the conversation.
+func Trim(turns []entity.Turn, budget int) []entity.Turn {
+	if budget <= 0 {
+		return turns
+	}
+	perTurn := max(budget/4, 1)
+
+	var res []entity.Turn
+	used := 0
+	for i := len(turns) - 1; i >= 0; i-- {
+		t := turns[i]
+		t.Text = truncate(t.Text, perTurn)
+
+		cost := Tokens(t.Text)
+		if used+cost > budget {
+			break
+		}
+		used += cost
+		res = append(res, t)
+	}
+
+	slices.Reverse(res)
+	return res
+}
+
+// Tokens estimates the tokens of text, about four characters each for the
+// Latin and Cyrillic text the bot sees.
+func Tokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+func truncate(text string, tokens int) string {
+	r := []rune(text)
+	limit := tokens * 4
+	if len(r) <= limit {
+		return text
+	}
+	return string(r[:limit-1]) + "…"
+}
+
+func organizationNames(orgs any) []string {
+	if orgs == nil {
+		return nil
+	}
+	b, err := json.Marshal(orgs)
+	if err != nil {
+		return nil
+	}
+	var named []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(b, &named); err != nil {
+		return nil
+	}
+
+	var res []string
+	for _, o := range named {
+		if o.Name != "" {
+			res = append(res, o.Name)
+		}
+	}
+	return res
+}
+
+func encode(turns []entity.Turn) ([]any, error) {
+	values := make([]any, 0, len(turns))
+	for _, t := range turns {
+		b, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, string(b))
+	}
+	return values, nil
+}
+
+func decode(values []string) ([]entity.Turn, error) {
+	turns := make([]entity.Turn, 0, len(values))
+	for _, v := range values {
+		var t entity.Turn
+		if err := json.Unmarshal([]byte(v), &t); err != nil {
+			return nil, err
+		}
+		turns = append(turns, t)
+	}
+	return turns, nil
+}
+
+func cacheKey(chatRoomID string) string {
+	return fmt.Sprintf("chat:%s:memory", chatRoomID)
+}
+
+func summaryKey(chatRoomID string) string {
+	return fmt.Sprintf("chat:%s:summary", chatRoomID)
+}
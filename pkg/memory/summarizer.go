@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"chatbot/internal/entity"
+)
+
+// _maxFold bounds the exchanges folded into a summary in one call, so a long
+// backlog is caught up over several runs instead of in one huge prompt.
+const _maxFold = 50
+
+// LLM writes summaries.
+type LLM interface {
+	Summarize(ctx context.Context, summary string, turns []entity.Turn) (string, error)
+}
+
+// Summarizer folds the older exchanges of long chat rooms into their
+// summaries. The window keeps every exchange the summary does not cover as
+// long as the job keeps up: a room is folded once its unsummarized
+// exchanges fill the window, down to the window minus every.
+type Summarizer struct {
+	memory *ConversationMemory
+	llm    LLM
+	keep   int
+	every  int
+	batch  int
+}
+
+// NewSummarizer -.
+func NewSummarizer(memory *ConversationMemory, llm LLM, every, batch int) *Summarizer {
+	every = max(min(every, memory.maxExchanges), 1)
+	return &Summarizer{
+		memory: memory,
+		llm:    llm,
+		keep:   memory.maxExchanges - every,
+		every:  every,
+		batch:  batch,
+	}
+}
+
+// Run summarizes a batch of rooms every interval until ctx is done.
+func (s *Summarizer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.SummarizeRooms(ctx); err != nil {
+				slog.Error("Conversation summarization failed", "err", err)
+			}
+		}
+	}
+}
+
+// SummarizeRooms refreshes the summaries of up to one batch of rooms.
+func (s *Summarizer) SummarizeRooms(ctx context.Context) error {
+	rooms, err := s.memory.ChatRepoI.GetRoomsToSummarize(ctx, s.keep, s.every, s.batch)
+	if err != nil {
+		return err
+	}
+
+	for _, room := range rooms {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.Summarize(ctx, room); err != nil {
+			slog.Warn("Failed to summarize chat room", "chat_room_id", room.ChatRoomID, "err", err)
+		}
+	}
+
+	return nil
+}
+
+// Summarize folds the exchanges between the summary of room and its kept
+// recent ones into the summary.
+func (s *Summarizer) Summarize(ctx context.Context, room entity.ChatSummary) error {
+	fold := min(room.Total-room.Exchanges-s.keep, _maxFold)
+	if fold <= 0 {
+		return nil
+	}
+
+	turns, err := s.memory.ChatRepoI.GetTurns(ctx, room.ChatRoomID, room.Exchanges, fold)
+	if err != nil {
+		return err
+	}
+
+	summary, err := s.llm.Summarize(ctx, room.Text, turns)
+	if err != nil {
+		return err
+	}
+	if summary == "" {
+		// Keep the old summary rather than lose it to an empty answer.
+		return nil
+	}
+
+	return s.memory.SaveSummary(ctx, room.ChatRoomID, summary, room.Exchanges+fold)
+}
@@ -0,0 +1,174 @@
+// Package prompt keeps the prompt templates used by the LLM and Sonar calls.
+// Templates ship as files (version 0) and can be overridden at runtime by
+// versions stored in the prompts table.
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"chatbot/internal/entity"
+)
+
+const (
+	Router            = "router"
+	OrganizationMerge = "organization_merge"
+	SonarSystem       = "sonar_system"
+	Summary           = "conversation_summary"
+
+	fileExt = ".tmpl"
+)
+
+// Store is where stored prompt versions come from.
+type Store interface {
+	GetActive(ctx context.Context) ([]entity.Prompt, error)
+}
+
+type entry struct {
+	tmpl    *template.Template
+	version int
+}
+
+// Registry renders the active version of every prompt. It is safe for
+// concurrent use and can be reloaded while serving.
+type Registry struct {
+	dir   string
+	store Store
+
+	mu      sync.RWMutex
+	entries map[string]entry
+}
+
+func New(dir string, store Store) *Registry {
+	return &Registry{
+		dir:     dir,
+		store:   store,
+		entries: map[string]entry{},
+	}
+}
+
+// Load reads the template files and then applies the active stored versions
+// on top of them.
+func (r *Registry) Load(ctx context.Context) error {
+	entries := map[string]entry{}
+
+	files, err := filepath.Glob(filepath.Join(r.dir, "*"+fileExt))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		body, err := os.ReadFile(f)
+		if err != nil {
+			return fmt.Errorf("prompt - Load - read %s: %w", f, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(f), fileExt)
+		tmpl, err := Parse(name, string(body))
+		if err != nil {
+			return err
+		}
+		entries[name] = entry{tmpl: tmpl, version: 0}
+	}
+
+	if r.store != nil {
+		stored, err := r.store.GetActive(ctx)
+		if err != nil {
+			return fmt.Errorf("prompt - Load - store.GetActive: %w", err)
+		}
+		for _, p := range stored {
+			tmpl, err := Parse(p.Name, p.Body)
+			if err != nil {
+				slog.Error("Skipping invalid stored prompt", "name", p.Name, "version", p.Version, "err", err)
+				continue
+			}
+			entries[p.Name] = entry{tmpl: tmpl, version: p.Version}
+		}
+	}
+
+	r.mu.Lock()
+	r.entries = entries
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads the registry every interval until ctx is done, so stored
+// activations and edited files are picked up without a redeploy.
+func (r *Registry) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Load(ctx); err != nil {
+				slog.Error("Prompt reload error", "err", err)
+			}
+		}
+	}
+}
+
+// Render executes the active version of the named prompt and returns the
+// text together with the version that produced it.
+func (r *Registry) Render(name string, data any) (string, int, error) {
+	r.mu.RLock()
+	e, ok := r.entries[name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", 0, fmt.Errorf("prompt %q not found", name)
+	}
+
+	text, err := execute(e.tmpl, data)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return text, e.version, nil
+}
+
+// Version returns the active version of the named prompt.
+func (r *Registry) Version(name string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.entries[name].version
+}
+
+// Parse compiles a prompt body. Missing fields are reported as errors so a
+// typo in a new version is caught on preview, not in production.
+func Parse(name, body string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("prompt - Parse - %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// Preview renders body with data without touching the registry.
+func Preview(name, body string, data any) (string, error) {
+	tmpl, err := Parse(name, body)
+	if err != nil {
+		return "", err
+	}
+	return execute(tmpl, data)
+}
+
+func execute(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("prompt - Render - %s: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
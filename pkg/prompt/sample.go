@@ -0,0 +1,46 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknown is returned for a prompt no package renders.
+var ErrUnknown = errors.New("unknown prompt")
+
+var (
+	samplesMu sync.RWMutex
+	samples   = map[string]any{}
+)
+
+// Register records sample data of the type the named prompt is rendered
+// with. The packages rendering a prompt register it on init, so a new
+// version referring to a field the prompt never gets is caught by Check.
+func Register(name string, sample any) {
+	samplesMu.Lock()
+	defer samplesMu.Unlock()
+	samples[name] = sample
+}
+
+// Sample returns the sample data registered for the named prompt.
+func Sample(name string) (any, error) {
+	samplesMu.RLock()
+	defer samplesMu.RUnlock()
+	sample, ok := samples[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknown, name)
+	}
+	return sample, nil
+}
+
+// Check compiles body and renders it with the sample data of the named
+// prompt.
+func Check(name, body string) error {
+	sample, err := Sample(name)
+	if err != nil {
+		return err
+	}
+	_, err = Preview(name, body, sample)
+	return err
+}
@@ -0,0 +1,64 @@
+package prompt_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	// The packages rendering the prompts register their samples.
+	_ "chatbot/pkg/gemini"
+	"chatbot/pkg/prompt"
+	_ "chatbot/pkg/sonar"
+)
+
+func TestCheckShippedPrompts(t *testing.T) {
+	files, err := filepath.Glob("../../prompts/*.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no prompt files")
+	}
+
+	for _, f := range files {
+		body, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := strings.TrimSuffix(filepath.Base(f), ".tmpl")
+		if err := prompt.Check(name, string(body)); err != nil {
+			t.Errorf("Check(%q) error = %v", name, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		prompt  string
+		body    string
+		wantErr bool
+		unknown bool
+	}{
+		{name: "valid", prompt: prompt.Router, body: "Q: {{.Question}} in {{.Language}}"},
+		{name: "unknown field", prompt: prompt.Router, body: "Q: {{.Qestion}}", wantErr: true},
+		{name: "field of another prompt", prompt: prompt.Summary, body: "{{.Question}}", wantErr: true},
+		{name: "field inside range", prompt: prompt.SonarSystem, body: "{{range .Documents}}{{.Text}}{{end}}", wantErr: true},
+		{name: "syntax error", prompt: prompt.Router, body: "{{if .Question}}", wantErr: true},
+		{name: "unknown prompt", prompt: "routr", body: "{{.Question}}", wantErr: true, unknown: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := prompt.Check(tt.prompt, tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check(%q, %q) error = %v, want error %v", tt.prompt, tt.body, err, tt.wantErr)
+			}
+			if errors.Is(err, prompt.ErrUnknown) != tt.unknown {
+				t.Errorf("Check(%q) error = %v, want ErrUnknown %v", tt.prompt, err, tt.unknown)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package sonar
+
+import (
+	"chatbot/internal/entity"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// FakeAnswer is what the fake server replies with.
+type FakeAnswer struct {
+	Chunks        []string
+	Citations     []string
+	Organizations []entity.OrgInfo
+}
+
+// NewFakeServer starts a local server speaking the Perplexity chat
+// completions protocol. Point Perplexity (or SONAR_BASE_URL) at its URL to
+// run without the network. The caller closes it.
+func NewFakeServer(answer FakeAnswer) *httptest.Server {
+	return httptest.NewServer(FakeHandler(answer))
+}
+
+// FakeHandler answers with SSE chunks, the way Perplexity streams. The
+// citations come with the first chunk. Organizations, if set, replace the
+// text chunks and are streamed as a JSON array cut into small pieces.
+func FakeHandler(answer FakeAnswer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req struct {
+			Stream bool `json:"stream"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Stream {
+			http.Error(w, "want a streamed request", http.StatusBadRequest)
+			return
+		}
+
+		chunks := answer.Chunks
+		if answer.Organizations != nil {
+			chunks = splitN(string(mustJSON(answer.Organizations)), 32)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, _ := w.(http.Flusher)
+
+		for i, chunk := range chunks {
+			data := map[string]any{
+				"id":    "fake",
+				"model": "sonar",
+				"choices": []map[string]any{
+					{"index": 0, "delta": map[string]string{"content": chunk}},
+				},
+			}
+			if i == 0 {
+				data["citations"] = answer.Citations
+			}
+
+			fmt.Fprintf(w, "data: %s\n\n", mustJSON(data))
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+}
+
+func splitN(s string, n int) []string {
+	var out []string
+	for len(s) > n {
+		out = append(out, s[:n])
+		s = s[n:]
+	}
+	return append(out, s)
+}
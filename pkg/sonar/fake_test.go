@@ -0,0 +1,80 @@
+package sonar
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"chatbot/internal/entity"
+)
+
+func TestFakeServer(t *testing.T) {
+	orgs := []entity.OrgInfo{
+		{Name: "Hamkorbank", Description: `Bank "Hamkor" {tijorat}`, Address: "Andijon, Bobur shoh ko‘chasi 85"},
+		{Name: "Kapitalbank", Phone: "+998 71 200 15 15"},
+	}
+
+	tests := []struct {
+		name       string
+		answer     FakeAnswer
+		structured bool
+		text       string
+		orgs       []string
+	}{
+		{
+			name:   "text",
+			answer: FakeAnswer{Chunks: []string{"Hamkorbank ", "Andijonda ", "joylashgan."}, Citations: []string{"https://hamkorbank.uz", "https://hamkorbank.uz"}},
+			text:   "Hamkorbank Andijonda joylashgan.",
+		},
+		{
+			name:       "organizations cut into pieces",
+			answer:     FakeAnswer{Organizations: orgs, Citations: []string{"https://hamkorbank.uz"}},
+			structured: true,
+			orgs:       []string{"Hamkorbank", "Kapitalbank"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewFakeServer(tt.answer)
+			defer srv.Close()
+			p := NewPerplexity(srv.URL, "test", "", srv.Client())
+
+			events, err := p.Search(context.Background(), SearchRequest{Query: "Hamkorbank", Structured: tt.structured})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var text strings.Builder
+			var citations, names []string
+			var last Event
+			for _, ev := range collect(t, events) {
+				switch ev.Type {
+				case EventText:
+					text.WriteString(ev.Text)
+				case EventCitation:
+					citations = append(citations, ev.URL)
+				case EventOrganization:
+					names = append(names, ev.Organization.Name)
+				case EventError:
+					t.Fatalf("error event: %v", ev.Err)
+				}
+				last = ev
+			}
+
+			if text.String() != tt.text {
+				t.Errorf("text = %q, want %q", text.String(), tt.text)
+			}
+			if !reflect.DeepEqual(names, tt.orgs) {
+				t.Errorf("organizations = %q, want %q", names, tt.orgs)
+			}
+			if want := []string{"https://hamkorbank.uz"}; !reflect.DeepEqual(citations, want) {
+				t.Errorf("citations = %q, want %q", citations, want)
+			}
+			if last.Type != EventDone {
+				t.Errorf("last event = %q, want done", last.Type)
+			}
+		})
+	}
+}
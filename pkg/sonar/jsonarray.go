@@ -0,0 +1,67 @@
+package sonar
+
+import "strings"
+
+// arrayScanner cuts the elements of a top-level JSON array out of a text
+// that arrives in arbitrary pieces, so each element can be decoded as soon
+// as it is complete. Anything before the opening bracket (e.g. a ```json
+// fence) is skipped.
+type arrayScanner struct {
+	buf     strings.Builder
+	started bool
+	depth   int
+	inStr   bool
+	escaped bool
+	start   int
+}
+
+// Write feeds the next piece of text and returns the elements it completed.
+func (s *arrayScanner) Write(text string) []string {
+	var out []string
+
+	for _, r := range text {
+		if !s.started {
+			if r == '[' {
+				s.started = true
+				s.depth = 1
+			}
+			continue
+		}
+		if s.depth == 0 {
+			// The array is closed, ignore trailing text.
+			continue
+		}
+
+		s.buf.WriteRune(r)
+		pos := s.buf.Len()
+
+		if s.inStr {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case r == '\\':
+				s.escaped = true
+			case r == '"':
+				s.inStr = false
+			}
+			continue
+		}
+
+		switch r {
+		case '"':
+			s.inStr = true
+		case '{', '[':
+			if s.depth == 1 {
+				s.start = pos - 1
+			}
+			s.depth++
+		case '}', ']':
+			s.depth--
+			if s.depth == 1 {
+				out = append(out, s.buf.String()[s.start:pos])
+			}
+		}
+	}
+
+	return out
+}
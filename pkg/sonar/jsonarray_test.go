@@ -0,0 +1,96 @@
+package sonar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArrayScanner(t *testing.T) {
+	tests := []struct {
+		name   string
+		pieces []string
+		want   []string
+	}{
+		{
+			name:   "whole array",
+			pieces: []string{`[{"name":"A"},{"name":"B"}]`},
+			want:   []string{`{"name":"A"}`, `{"name":"B"}`},
+		},
+		{
+			name:   "split everywhere",
+			pieces: []string{`[`, `{"na`, `me":`, `"A"`, `}`, `,`, ` {"name"`, `:"B","loc`, `ation":{"latitude":41.3}`, `}]`},
+			want:   []string{`{"name":"A"}`, `{"name":"B","location":{"latitude":41.3}}`},
+		},
+		{
+			name:   "fence before the array",
+			pieces: []string{"```json\n", `[{"name":"A"}]`, "\n```"},
+			want:   []string{`{"name":"A"}`},
+		},
+		{
+			name:   "escaped quotes in strings",
+			pieces: []string{`[{"name":"Bank \"Hamkor\""},{"name":"\\"}]`},
+			want:   []string{`{"name":"Bank \"Hamkor\""}`, `{"name":"\\"}`},
+		},
+		{
+			name:   "escape split from its quote",
+			pieces: []string{`[{"name":"Bank \`, `"Hamkor\`, `"", "x":1}]`},
+			want:   []string{`{"name":"Bank \"Hamkor\"", "x":1}`},
+		},
+		{
+			name:   "braces and brackets in strings",
+			pieces: []string{`[{"description":"{tijorat} [ATB] }}]"},{"name":"]"}]`},
+			want:   []string{`{"description":"{tijorat} [ATB] }}]"}`, `{"name":"]"}`},
+		},
+		{
+			name:   "nested arrays",
+			pieces: []string{`[{"sources":["a","b"],"images_url":[]}]`},
+			want:   []string{`{"sources":["a","b"],"images_url":[]}`},
+		},
+		{
+			name:   "non-ASCII text",
+			pieces: []string{`[{"address":"Bobur shoh ko‘ch`, `asi 85"}]`},
+			want:   []string{`{"address":"Bobur shoh ko‘chasi 85"}`},
+		},
+		{
+			name:   "truncated trailing object",
+			pieces: []string{`[{"name":"A"},{"name":"B","address":"Tosh`},
+			want:   []string{`{"name":"A"}`},
+		},
+		{
+			name:   "text after the array",
+			pieces: []string{`[{"name":"A"}] and [{"name":"B"}]`},
+			want:   []string{`{"name":"A"}`},
+		},
+		{
+			name:   "no array",
+			pieces: []string{"No reliable information available."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s arrayScanner
+			var got []string
+			for _, p := range tt.pieces {
+				got = append(got, s.Write(p)...)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("elements = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Every way of cutting the text gives the same elements.
+func TestArrayScannerEverySplit(t *testing.T) {
+	text := `[{"name":"Bank \"Hamkor\" {x}","sources":["a"]},{"name":"B"}]`
+	want := []string{`{"name":"Bank \"Hamkor\" {x}","sources":["a"]}`, `{"name":"B"}`}
+
+	for i := 0; i <= len(text); i++ {
+		var s arrayScanner
+		got := append(s.Write(text[:i]), s.Write(text[i:])...)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("split at %d: elements = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package sonar
+
+import (
+	"bufio"
+	"bytes"
+	"chatbot/internal/entity"
+	"chatbot/pkg/httpclient"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	_defaultBaseURL = "https://api.perplexity.ai"
+	_defaultModel   = "sonar"
+)
+
+var searchDomainFilter = []string{
+	".uz", "www.yellowpages.uz", "www.goldenpages.uz", "https://orginfo.uz/",
+}
+
+// orgsSchema is the structured output contract of a Structured search.
+var orgsSchema = map[string]any{
+	"type": "array",
+	"items": map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":    map[string]string{"type": "string"},
+			"address": map[string]string{"type": "string"},
+			"location": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"latitude":  map[string]string{"type": "number"},
+					"longitude": map[string]string{"type": "number"},
+				},
+				"required": []string{"latitude", "longitude"},
+			},
+			"phone":       map[string]string{"type": "string"},
+			"email":       map[string]string{"type": "string"},
+			"description": map[string]string{"type": "string"},
+			"website":     map[string]string{"type": "string"},
+			"sources": map[string]any{
+				"type":  "array",
+				"items": map[string]string{"type": "string"},
+			},
+			"images_url": map[string]any{
+				"type":  "array",
+				"items": map[string]string{"type": "string"},
+			},
+		},
+		"required": []string{"name", "address"},
+	},
+}
+
+type ppSearchResult struct {
+	Title string  `json:"title"`
+	URL   string  `json:"url"`
+	Date  *string `json:"date,omitempty"`
+}
+
+type ppStreamChunk struct {
+	ID            string           `json:"id"`
+	Object        string           `json:"object"`
+	Model         string           `json:"model"`
+	Citations     []string         `json:"citations,omitempty"`
+	SearchResults []ppSearchResult `json:"search_results,omitempty"`
+
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Role    string `json:"role,omitempty"`
+			Content string `json:"content,omitempty"`
+		} `json:"delta"`
+		Message *struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"message,omitempty"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+
+	Error *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error,omitempty"`
+}
+
+// Perplexity is the SearchProvider backed by the Perplexity chat completions
+// API. The base URL is configurable so it can point at a local fake server.
+type Perplexity struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+func NewPerplexity(baseURL, apiKey, model string, client *http.Client) *Perplexity {
+	if baseURL == "" {
+		baseURL = _defaultBaseURL
+	}
+	if model == "" {
+		model = _defaultModel
+	}
+	if client == nil {
+		client = httpclient.New()
+	}
+
+	return &Perplexity{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  client,
+	}
+}
+
+func (p *Perplexity) Search(ctx context.Context, req SearchRequest) (<-chan Event, error) {
+	payload := map[string]any{
+		"model": p.model,
+		"messages": []map[string]string{
+			{"role": "system", "content": req.System},
+			{"role": "user", "content": req.Query},
+		},
+		"web_search_options": map[string]any{
+			"user_location":        map[string]string{"country": "UZ"},
+			"search_context_size":  req.ContextSize,
+			"search_domain_filter": searchDomainFilter,
+		},
+		"stream": true,
+	}
+	if req.Structured {
+		payload["response_format"] = map[string]any{
+			"type":        "json_schema",
+			"json_schema": map[string]any{"schema": orgsSchema},
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewBuffer(mustJSON(payload)))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if err := httpclient.CheckStatus(resp); err != nil {
+		return nil, fmt.Errorf("sonar - Search: %w", err)
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		e := emitter{ctx: ctx, ch: events}
+
+		var err error
+		ct := strings.ToLower(resp.Header.Get("Content-Type"))
+		if strings.HasPrefix(ct, "text/event-stream") {
+			err = e.readStream(resp.Body, req.Structured)
+		} else {
+			err = e.readBody(resp.Body, req.Structured)
+		}
+		if err != nil {
+			e.send(Event{Type: EventError, Err: err})
+			return
+		}
+		e.send(Event{Type: EventDone})
+	}()
+
+	return events, nil
+}
+
+// emitter turns Perplexity chunks into events, sending every citation and
+// search result URL once.
+type emitter struct {
+	ctx  context.Context
+	ch   chan<- Event
+	seen map[string]struct{}
+	orgs arrayScanner
+}
+
+func (e *emitter) send(ev Event) bool {
+	select {
+	case e.ch <- ev:
+		return true
+	case <-e.ctx.Done():
+		return false
+	}
+}
+
+func (e *emitter) sources(citations []string, results []ppSearchResult) bool {
+	if e.seen == nil {
+		e.seen = map[string]struct{}{}
+	}
+
+	for _, u := range citations {
+		if _, ok := e.seen[u]; ok || strings.TrimSpace(u) == "" {
+			continue
+		}
+		e.seen[u] = struct{}{}
+		if !e.send(Event{Type: EventCitation, URL: u}) {
+			return false
+		}
+	}
+
+	for _, sr := range results {
+		key := "sr:" + sr.URL
+		if _, ok := e.seen[key]; ok || strings.TrimSpace(sr.URL) == "" {
+			continue
+		}
+		e.seen[key] = struct{}{}
+
+		ev := Event{Type: EventSearchResult, URL: sr.URL, Title: sr.Title}
+		if sr.Date != nil {
+			ev.Date = *sr.Date
+		}
+		if !e.send(ev) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// content sends a piece of the answer. In structured mode the pieces are
+// JSON, and an organization is sent once its object is closed.
+func (e *emitter) content(text string, structured bool) bool {
+	if !structured {
+		return e.send(Event{Type: EventText, Text: text})
+	}
+
+	for _, raw := range e.orgs.Write(text) {
+		var org entity.OrgInfo
+		if err := json.Unmarshal([]byte(raw), &org); err != nil {
+			slog.Warn("failed to parse structured organization", "error", err)
+			continue
+		}
+		if !e.send(Event{Type: EventOrganization, Organization: org}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *emitter) readStream(body io.Reader, structured bool) error {
+	scanner := bufio.NewScanner(body)
+	const maxBuf = 1024 * 1024
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, maxBuf)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk ppStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			slog.Warn("failed to unmarshal SSE chunk", "error", err)
+			continue
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("sonar stream error: %s", chunk.Error.Message)
+		}
+
+		if !e.sources(chunk.Citations, chunk.SearchResults) {
+			return e.ctx.Err()
+		}
+
+		for _, ch := range chunk.Choices {
+			if s := ch.Delta.Content; s != "" {
+				if !e.content(s, structured) {
+					return e.ctx.Err()
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("stream read error: %v", err)
+	}
+
+	return nil
+}
+
+func (e *emitter) readBody(body io.Reader, structured bool) error {
+	all, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	var chunk ppStreamChunk
+	if err := json.Unmarshal(all, &chunk); err != nil {
+		return fmt.Errorf("non-stream parse error: %v; body: %s", err, string(all))
+	}
+	if chunk.Error != nil {
+		return fmt.Errorf("sonar error: %s", chunk.Error.Message)
+	}
+	if len(chunk.Choices) == 0 || chunk.Choices[0].Message == nil {
+		return fmt.Errorf("no choices returned from Sonar")
+	}
+	content := chunk.Choices[0].Message.Content
+
+	if !e.sources(chunk.Citations, chunk.SearchResults) {
+		return e.ctx.Err()
+	}
+
+	if !e.content(content, structured) {
+		return e.ctx.Err()
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package sonar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// replay serves a recorded response body with the given content type.
+func replay(t *testing.T, file, contentType string) *Perplexity {
+	t.Helper()
+
+	body, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewPerplexity(srv.URL, "test", "", srv.Client())
+}
+
+func collect(t *testing.T, events <-chan Event) []Event {
+	t.Helper()
+
+	var res []Event
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return res
+			}
+			res = append(res, ev)
+		case <-timeout:
+			t.Fatal("stream did not end")
+		}
+	}
+}
+
+func TestPerplexityStream(t *testing.T) {
+	p := replay(t, "testdata/perplexity_text.sse", "text/event-stream; charset=utf-8")
+
+	events, err := p.Search(context.Background(), SearchRequest{Query: "Hamkorbank qayerda"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text strings.Builder
+	var citations, results []string
+	var last Event
+	for _, ev := range collect(t, events) {
+		switch ev.Type {
+		case EventText:
+			text.WriteString(ev.Text)
+		case EventCitation:
+			citations = append(citations, ev.URL)
+		case EventSearchResult:
+			results = append(results, ev.Title+" "+ev.Date)
+		case EventError:
+			t.Fatalf("error event: %v", ev.Err)
+		}
+		last = ev
+	}
+
+	// The broken chunk is skipped, nothing after [DONE] is read.
+	if got, want := text.String(), "Hamkorbank bosh ofisi Andijon shahrida, Bobur shoh ko‘chasi 85-uyda joylashgan[1]."; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	// Every chunk repeats the sources; each one is sent once.
+	wantCitations := []string{
+		"https://hamkorbank.uz/uz/contacts",
+		"https://yandex.uz/maps/org/hamkorbank/1128540612/",
+		"https://orginfo.uz/organization/hamkorbank",
+	}
+	if !reflect.DeepEqual(citations, wantCitations) {
+		t.Errorf("citations = %q, want %q", citations, wantCitations)
+	}
+	wantResults := []string{"Hamkorbank — kontaktlar 2025-03-14", "Hamkorbank, Andijon — Yandex Xaritalar "}
+	if !reflect.DeepEqual(results, wantResults) {
+		t.Errorf("search results = %q, want %q", results, wantResults)
+	}
+	if last.Type != EventDone {
+		t.Errorf("last event = %q, want done", last.Type)
+	}
+}
+
+func TestPerplexityStructuredStream(t *testing.T) {
+	p := replay(t, "testdata/perplexity_structured.sse", "text/event-stream")
+
+	events, err := p.Search(context.Background(), SearchRequest{Query: "Banklar", Structured: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	var last Event
+	for _, ev := range collect(t, events) {
+		switch ev.Type {
+		case EventOrganization:
+			names = append(names, ev.Organization.Name)
+			if ev.Organization.Name == "Hamkorbank" {
+				if want := `Bank "Hamkor" {tijorat} [ATB] \ filial`; ev.Organization.Description != want {
+					t.Errorf("description = %q, want %q", ev.Organization.Description, want)
+				}
+				if ev.Organization.Location.Latitude != 40.7821 {
+					t.Errorf("latitude = %v", ev.Organization.Location.Latitude)
+				}
+			}
+		case EventText:
+			t.Errorf("text event %q in structured mode", ev.Text)
+		}
+		last = ev
+	}
+
+	// The answer was cut inside the third organization.
+	if want := []string{"Hamkorbank", "Kapitalbank"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("organizations = %q, want %q", names, want)
+	}
+	if last.Type != EventDone {
+		t.Errorf("last event = %q, want done", last.Type)
+	}
+}
+
+func TestPerplexityStreamError(t *testing.T) {
+	p := replay(t, "testdata/perplexity_error.sse", "text/event-stream")
+
+	events, err := p.Search(context.Background(), SearchRequest{Query: "Hamkorbank"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := collect(t, events)
+	if len(got) != 2 || got[0].Type != EventText || got[1].Type != EventError {
+		t.Fatalf("events = %+v, want text then error", got)
+	}
+	if !strings.Contains(got[1].Err.Error(), "Rate limit exceeded") {
+		t.Errorf("error = %v", got[1].Err)
+	}
+}
+
+func TestPerplexityBody(t *testing.T) {
+	p := replay(t, "testdata/perplexity_body.json", "application/json")
+
+	events, err := p.Search(context.Background(), SearchRequest{Query: "Hamkorbank"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := collect(t, events)
+	want := []Event{
+		{Type: EventCitation, URL: "https://hamkorbank.uz"},
+		{Type: EventText, Text: "Hamkorbank Andijonda."},
+		{Type: EventDone},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %+v, want %+v", got, want)
+	}
+}
+
+func TestPerplexityCancel(t *testing.T) {
+	p := replay(t, "testdata/perplexity_text.sse", "text/event-stream")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := p.Search(ctx, SearchRequest{Query: "Hamkorbank"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody reads after the first event: the stream has to end anyway.
+	<-events
+	cancel()
+	collect(t, events)
+}
@@ -0,0 +1,67 @@
+package sonar
+
+import (
+	"chatbot/internal/entity"
+	"context"
+)
+
+type EventType string
+
+const (
+	EventText         EventType = "text"
+	EventCitation     EventType = "citation"
+	EventSearchResult EventType = "search_result"
+	EventOrganization EventType = "organization"
+	EventDone         EventType = "done"
+	EventError        EventType = "error"
+)
+
+// Event is one item of a search stream. Only the fields of its Type are set.
+type Event struct {
+	Type EventType
+
+	// EventText
+	Text string
+
+	// EventCitation, EventSearchResult
+	URL   string
+	Title string
+	Date  string
+
+	// EventOrganization
+	Organization entity.OrgInfo
+
+	// EventError
+	Err error
+}
+
+// SearchRequest -.
+type SearchRequest struct {
+	System string
+	Query  string
+
+	// ContextSize is "low", "medium" or "high".
+	ContextSize string
+
+	// Structured asks for organizations instead of a text answer. Each one
+	// is sent as its own EventOrganization as soon as it is complete.
+	Structured bool
+}
+
+// SearchProvider runs a web search backed answer. The returned channel is
+// closed after an EventDone or an EventError, or when ctx is done.
+type SearchProvider interface {
+	Search(ctx context.Context, req SearchRequest) (<-chan Event, error)
+}
+
+// Sink is where the events of an answer are written to, e.g. a
+// *wsproto.Message.
+type Sink interface {
+	ID() string
+	Delta(text string) error
+	Content(content entity.WSContentPayload) error
+	Organization(o entity.OrgInfo) error
+	Citation(d entity.DocumentCitation) error
+	Location(l entity.Location) error
+	Done() error
+}
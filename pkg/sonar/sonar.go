@@ -1,152 +1,59 @@
 package sonar
 
 import (
-	"bytes"
-	"chatbot/config"
 	"chatbot/internal/entity"
 	"chatbot/internal/usecase"
+	"chatbot/pkg/directory"
+	"chatbot/pkg/lang"
+	"chatbot/pkg/prompt"
+	"context"
 	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-
-	"github.com/gorilla/websocket"
-)
-
-const (
-	pplxAPIURL = "https://api.perplexity.ai/chat/completions"
 )
 
-var systemPrompt = `
-Respond to user queries by retrieving and presenting information on organizations in Uzbekistan only from reliable, verifiable sources (e.g., official government registries, reputable news outlets, recognized business directories, or accredited databases).
-
-Response Guidelines:
-
-Source Reliability: Only provide information if it can be verified by at least one reliable source.
-
-Transparency: Always cite the source(s) in your response.
-
-No Guesswork: If no reliable source is found, clearly state: "No reliable information available." Do not speculate, fabricate, or infer details.
-
-Geographic Scope: Only return results about organizations physically located in Uzbekistan.
-
-Relevance: Ensure the information directly answers the user's request without unrelated details.
-
-Neutrality: Present information factually and without bias. Avoid opinions or promotional language.
-
-Fail-safe Rule:
-If you cannot confirm the accuracy of the information or cannot locate a trustworthy source, you must respond with:
-"No reliable information available."
-Return the answer only in the language of the question given
-`
-
-func StreamToWS(cfg config.Config, db *usecase.UseCase, conn *websocket.Conn, userQuestion, geminiQuestion, chatRoomId string) error {
-	payload := map[string]any{
-		"model": "sonar",
-		"messages": []map[string]string{
-			{"role": "system", "content": systemPrompt},
-			{"role": "user", "content": geminiQuestion},
-		},
-		"web_search_options": map[string]any{
-			"user_location":       map[string]string{"country": "UZ"},
-			"search_context_size": "medium",
-			"search_domain_filter": []string{
-				".uz", "www.yellowpages.uz", "www.goldenpages.uz", "https://orginfo.uz/",
-			},
-		},
-		"response_format": map[string]any{
-			"type": "json_schema",
-			"json_schema": map[string]any{
-				"schema": map[string]any{
-					"type": "array",
-					"items": map[string]any{
-						"type": "object",
-						"properties": map[string]any{
-							"name":    map[string]string{"type": "string"},
-							"address": map[string]string{"type": "string"},
-							"location": map[string]any{
-								"type": "object",
-								"properties": map[string]any{
-									"latitude":  map[string]string{"type": "number"},
-									"longitude": map[string]string{"type": "number"},
-								},
-								"required": []string{"latitude", "longitude"},
-							},
-							"phone":       map[string]string{"type": "string"},
-							"email":       map[string]string{"type": "string"},
-							"description": map[string]string{"type": "string"},
-							"website":     map[string]string{"type": "string"},
-							"sources": map[string]any{
-								"type":  "array",
-								"items": map[string]string{"type": "string"},
-							},
-							"images_url": map[string]any{
-								"type":  "array",
-								"items": map[string]string{"type": "string"},
-							},
-						},
-						"required": []string{"name", "address"},
-					},
-				},
-			},
-		},
-
-		"stream": false,
-	}
-
-	// _ = conn.WriteJSON(map[string]any{
-	// 	"type":    "payload",
-	// 	"payload": payload,
-	// })
-
-	req, _ := http.NewRequest("POST", pplxAPIURL, bytes.NewBuffer(mustJSON(payload)))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+cfg.PerplexityAPIKey.Key)
-
-	resp, err := http.DefaultClient.Do(req)
+func StreamToWS(ctx context.Context, search SearchProvider, db *usecase.UseCase, prompts *prompt.Registry, conn Sink, userQuestion, geminiQuestion, chatRoomId, language string, documents []entity.DocumentCitation, promptVersions map[string]int) error {
+	system, version, err := buildSystemPrompt(prompts, language, documents)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	promptVersions[prompt.SonarSystem] = version
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
+	if err := writeDocumentCitations(conn, documents); err != nil {
 		return err
 	}
 
-	var raw map[string]any
-	if err := json.Unmarshal(body, &raw); err != nil {
+	events, err := search.Search(ctx, SearchRequest{
+		System:      system,
+		Query:       geminiQuestion,
+		ContextSize: "medium",
+		Structured:  true,
+	})
+	if err != nil {
 		return err
 	}
 
-	choices, ok := raw["choices"].([]any)
-	if !ok || len(choices) == 0 {
-		return fmt.Errorf("no choices returned from Sonar")
-	}
-
-	citationsAny, ok := raw["citations"].([]any)
-	if !ok {
-		return fmt.Errorf("invalid citations format from Sonar")
-	}
-
-	citations := make([]string, 0, len(citationsAny))
-	for _, c := range citationsAny {
-		s, ok := c.(string)
-		if !ok {
-			return fmt.Errorf("citation is not a string: %v", c)
+	// Every organization goes to the client as soon as it is parsed, the
+	// citations follow with the final content frame.
+	var citations []string
+	var orgs []entity.OrgInfo
+	for ev := range events {
+		switch ev.Type {
+		case EventCitation:
+			citations = append(citations, ev.URL)
+		case EventOrganization:
+			orgs = append(orgs, ev.Organization)
+			if err := conn.Organization(ev.Organization); err != nil {
+				return err
+			}
+		case EventError:
+			// A cancelled request fails with the context error.
+			if ctx.Err() == nil {
+				return ev.Err
+			}
 		}
-		citations = append(citations, s)
 	}
-
-	msg := choices[0].(map[string]any)["message"].(map[string]any)
-	content, ok := msg["content"].(string)
-	if !ok {
-		return fmt.Errorf("invalid response format from Sonar")
-	}
-
-	var orgs []entity.OrgInfo
-	if err := json.Unmarshal([]byte(content), &orgs); err != nil {
-		return fmt.Errorf("failed to parse structured content: %v", err)
+	if err := ctx.Err(); err != nil {
+		go SaveResponce(db, conn.ID(), userQuestion, chatRoomId, "", geminiQuestion, citations, nil, nil, orgs, documents, promptVersions, true)
+		return err
 	}
 
 	// res := &entity.Response{
@@ -154,22 +61,16 @@ func StreamToWS(cfg config.Config, db *usecase.UseCase, conn *websocket.Conn, us
 	// 	Data:      orgs,
 	// }
 
-	err = conn.WriteJSON(map[string]any{
-		"content": map[string]any{
-			"citations":     citations,
-			"organizations": orgs,
-		},
+	err = conn.Content(entity.WSContentPayload{
+		Citations:     citations,
+		Organizations: orgs,
+		Documents:     documents,
 	})
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(orgs)
-
-	err = conn.WriteJSON(map[string]any{
-		"status": "end",
-	})
-	if err != nil {
+	if err := conn.Done(); err != nil {
 		return err
 	}
 
@@ -178,8 +79,48 @@ func StreamToWS(cfg config.Config, db *usecase.UseCase, conn *websocket.Conn, us
 // 		return err
 // 	}"
 
-	go SaveResponce(db, userQuestion, chatRoomId, "", geminiQuestion, citations, nil, nil, orgs)
+	go SaveResponce(db, conn.ID(), userQuestion, chatRoomId, "", geminiQuestion, citations, nil, nil, orgs, documents, promptVersions, false)
+
+	directoryOrgs := make([]entity.Organization, 0, len(orgs))
+	for _, o := range orgs {
+		directoryOrgs = append(directoryOrgs, directory.FromOrgInfo(o))
+	}
+	go directory.Save(context.Background(), db.OrganizationRepo, directoryOrgs)
+
+	return nil
+}
+
+type systemData struct {
+	Language  string
+	Documents []entity.DocumentCitation
+}
+
+func init() {
+	prompt.Register(prompt.SonarSystem, systemData{
+		Language: "Uzbek",
+		Documents: []entity.DocumentCitation{{
+			Type:       entity.SnippetDocument,
+			DocumentID: "00000000-0000-0000-0000-000000000000",
+			Title:      "Kredit shartlari",
+			URL:        "https://example.uz/kredit",
+			Tags:       []string{"kredit"},
+			Excerpt:    "Foiz stavkasi yillik 24%.",
+		}},
+	})
+}
 
+func buildSystemPrompt(prompts *prompt.Registry, language string, documents []entity.DocumentCitation) (string, int, error) {
+	return prompts.Render(prompt.SonarSystem, systemData{Language: lang.Name(language), Documents: documents})
+}
+
+// writeDocumentCitations sends the knowledge base documents an answer is
+// grounded in before the answer itself, as citations of their own type.
+func writeDocumentCitations(conn Sink, documents []entity.DocumentCitation) error {
+	for _, d := range documents {
+		if err := conn.Citation(d); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -1,62 +1,21 @@
 package sonar
 
 import (
-	"bufio"
-	"bytes"
-	"chatbot/config"
 	"chatbot/internal/entity"
 	"chatbot/internal/usecase"
 	"chatbot/pkg/cache"
 	"chatbot/pkg/coords"
+	"chatbot/pkg/directory"
 	"chatbot/pkg/gemini"
+	"chatbot/pkg/prompt"
 	"context"
 	"encoding/json"
-	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
-	"net/url"
 	"strings"
 
-	"github.com/gorilla/websocket"
 	"github.com/redis/go-redis/v9"
 )
 
-type ppStreamChunk struct {
-	ID        string   `json:"id"`
-	Object    string   `json:"object"`
-	Model     string   `json:"model"`
-	Citations []string `json:"citations,omitempty"`
-
-	SearchResults []struct {
-		Title string  `json:"title"`
-		URL   string  `json:"url"`
-		Date  *string `json:"date,omitempty"`
-	} `json:"search_results,omitempty"`
-
-	Choices []struct {
-		Index int `json:"index"`
-		Delta struct {
-			Role    string `json:"role,omitempty"`
-			Content string `json:"content,omitempty"`
-		} `json:"delta"`
-		Message *struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		} `json:"message,omitempty"`
-		FinishReason *string `json:"finish_reason"`
-	} `json:"choices"`
-
-	Error *struct {
-		Message string `json:"message"`
-		Type    string `json:"type"`
-	} `json:"error,omitempty"`
-	Location *struct {
-		Lat float64 `json:"latitude"`
-		Lng float64 `json:"longitude"`
-	} `json:"location,omitempty"`
-}
-
 // var systemPrompt2 = `
 // Respond to user queries by retrieving and presenting information on organizations in Uzbekistan only from reliable, verifiable sources (e.g., official registries, business directories, or government databases).
 
@@ -88,247 +47,145 @@ type ppStreamChunk struct {
 //      }
 // `
 
-func StreamToWSOneOrg(cfg config.Config, db *usecase.UseCase, redis redis.Client, conn *websocket.Conn, userQuestion, geminiQuestion, chatRoomId string) error {
-
-	payload := map[string]any{
-		"model": "sonar",
-		"messages": []map[string]string{
-			{"role": "system", "content": systemPrompt},
-			{"role": "user", "content": geminiQuestion},
-		},
-		"web_search_options": map[string]any{
-			"user_location":       map[string]string{"country": "UZ"},
-			"search_context_size": "high",
-			"search_domain_filter": []string{
-				".uz", "www.yellowpages.uz", "www.goldenpages.uz", "https://orginfo.uz/",
-			},
-		},
-		"stream": true,
-	}
-
-	req, _ := http.NewRequest("POST", pplxAPIURL, bytes.NewBuffer(mustJSON(payload)))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+cfg.PerplexityAPIKey.Key)
-	req.Header.Set("Accept", "text/event-stream")
-
-	resp, err := http.DefaultClient.Do(req)
+func StreamToWSOneOrg(ctx context.Context, search SearchProvider, resolver *coords.Resolver, db *usecase.UseCase, llm *gemini.Service, prompts *prompt.Registry, redis redis.Client, conn Sink, userQuestion, geminiQuestion, chatRoomId, language string, documents []entity.DocumentCitation, promptVersions map[string]int) error {
+	system, version, err := buildSystemPrompt(prompts, language, documents)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	promptVersions[prompt.SonarSystem] = version
 
-	ct := resp.Header.Get("Content-Type")
-	if !strings.HasPrefix(strings.ToLower(ct), "text/event-stream") {
-		return handleNonStream(db, cfg, redis, conn, resp.Body, userQuestion, geminiQuestion, chatRoomId)
+	if err := writeDocumentCitations(conn, documents); err != nil {
+		return err
 	}
 
-	scanner := bufio.NewScanner(resp.Body)
-	const maxBuf = 1024 * 1024
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, maxBuf)
+	events, err := search.Search(ctx, SearchRequest{
+		System:      system,
+		Query:       geminiQuestion,
+		ContextSize: "high",
+	})
+	if err != nil {
+		return err
+	}
 
 	var fullText string
 	citeSeen := map[string]struct{}{}
 	var citations []string
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" || strings.HasPrefix(line, ":") ||
-			strings.HasPrefix(line, "event:") || strings.HasPrefix(line, "id:") {
-			continue
-		}
-		if !strings.HasPrefix(line, "data:") {
-			continue
-		}
-
-		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
-		if data == "[DONE]" {
-			break
-		}
-
-		var chunk ppStreamChunk
-		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
-			slog.Warn("failed to unmarshal SSE chunk", "error", err)
-			continue
-		}
-		if chunk.Error != nil {
-			return fmt.Errorf("sonar stream error: %s", chunk.Error.Message)
-		}
-
-		for _, u := range chunk.Citations {
-			if _, ok := citeSeen[u]; !ok && strings.TrimSpace(u) != "" {
-				citeSeen[u] = struct{}{}
-				citations = append(citations, u)
+	for ev := range events {
+		switch ev.Type {
+		case EventText:
+			fullText += ev.Text
+			// A client too slow to take the stream ends it.
+			if err := conn.Delta(ev.Text); err != nil {
+				return err
 			}
-		}
-		for _, sr := range chunk.SearchResults {
-			if _, ok := citeSeen[sr.URL]; !ok && strings.TrimSpace(sr.URL) != "" {
-				citeSeen[sr.URL] = struct{}{}
-				citations = append(citations, sr.URL)
+		case EventCitation, EventSearchResult:
+			if _, ok := citeSeen[ev.URL]; !ok {
+				citeSeen[ev.URL] = struct{}{}
+				citations = append(citations, ev.URL)
 			}
-		}
-
-		for _, ch := range chunk.Choices {
-			if s := ch.Delta.Content; s != "" {
-				fullText += s
-				_ = conn.WriteJSON(map[string]any{
-					"text": s,
-				})
+		case EventError:
+			// A cancelled request fails with the context error.
+			if ctx.Err() == nil {
+				return ev.Err
 			}
 		}
 	}
-
-	if err := scanner.Err(); err != nil && err != io.EOF {
-		return fmt.Errorf("stream read error: %v", err)
+	if err := ctx.Err(); err != nil {
+		go SaveResponce(db, conn.ID(), userQuestion, chatRoomId, fullText, geminiQuestion, citations, nil, nil, nil, documents, promptVersions, true)
+		return err
 	}
 
-	var locations []map[string]float64
+	var locations []entity.Location
 
 	for _, v := range citations {
-		if strings.Contains(v, "maps.google.com") || strings.Contains(v, "google.com/maps") {
-			u, err := url.Parse(v)
-			if err != nil {
-				continue
-			}
-
-			if strings.Contains(u.Path, "@") {
-				parts := strings.Split(u.Path, "@")
-				if len(parts) > 1 {
-					coords := strings.Split(parts[1], ",")
-					if len(coords) >= 2 {
-						lat := coords[0]
-						lng := coords[1]
-						locations = append(locations, map[string]float64{
-							"latitude":  parseFloat(lat),
-							"longitude": parseFloat(lng),
-						})
-					}
-				}
-			}
-
-			q := u.Query().Get("q")
-			if q != "" {
-				coords := strings.Split(q, ",")
-				if len(coords) >= 2 {
-					lat := coords[0]
-					lng := coords[1]
-					locations = append(locations, map[string]float64{
-						"latitude":  parseFloat(lat),
-						"longitude": parseFloat(lng),
-					})
-				}
-			}
-		} else if strings.Contains(v, "https://yandex.uz/maps/org") || strings.Contains(v, "https://yandex.com/maps/org") {
+		if !coords.IsMapURL(v) {
+			continue
+		}
 
-			lat, lng, err := coords.ExtractCoordinates(v)
-			if err == nil {
-				locations = append(locations, map[string]float64{
-					"latitude":  lat,
-					"longitude": lng,
-				})
-			}
+		lat, lng, err := resolver.Resolve(ctx, v)
+		if err != nil {
+			slog.Warn("Failed to resolve map URL", "url", v, "err", err)
+			continue
+		}
+		location := entity.Location{Latitude: lat, Longitude: lng}
+		locations = append(locations, location)
+		if err := conn.Location(location); err != nil {
+			return err
 		}
 	}
 
-	var finalLocations []map[string]float64
+	var finalLocations []entity.Location
 	if len(locations) > 0 {
 		finalLocations = locations
 	} else {
 		finalLocations = nil
 	}
+	if err := ctx.Err(); err != nil {
+		go SaveResponce(db, conn.ID(), userQuestion, chatRoomId, fullText, geminiQuestion, citations, finalLocations, nil, nil, documents, promptVersions, true)
+		return err
+	}
 
 	images := extractImageURLs(citations)
 
-	_ = conn.WriteJSON(map[string]any{
-		"content": map[string]any{
-			"text":          fullText,
-			"citations":     citations,
-			"location":      finalLocations,
-			"images_url":    images,
-			"organizations": nil,
-		},
-	})
-
-	err = conn.WriteJSON(map[string]any{
-		"status": "end",
+	err = conn.Content(entity.WSContentPayload{
+		Text:      fullText,
+		Citations: citations,
+		Location:  finalLocations,
+		ImagesURL: images,
+		Documents: documents,
 	})
 	if err != nil {
 		return err
 	}
 
-	go SaveResponce(db, userQuestion, chatRoomId, fullText, geminiQuestion, citations, finalLocations, images, nil)
+	if err := conn.Done(); err != nil {
+		return err
+	}
+
+	go SaveResponce(db, conn.ID(), userQuestion, chatRoomId, fullText, geminiQuestion, citations, finalLocations, images, nil, documents, promptVersions, false)
 	organizationsJson, err := cache.GetChatOrganizations(&redis, context.Background(), "o"+chatRoomId, int64(5))
-	go gemini.OrganizationCreate(cfg, redis, fullText, organizationsJson, chatRoomId)
+	if err != nil {
+		// The answer is sent already; the new organizations are still
+		// extracted, only without the ones known from earlier turns.
+		slog.Warn("Failed to get chat organizations", "chat_room_id", chatRoomId, "error", err)
+	}
+	go func() {
+		extracted := llm.OrganizationCreate(context.Background(), redis, fullText, organizationsJson, chatRoomId)
 
-	return nil
-}
+		directory.Save(context.Background(), db.OrganizationRepo, answerOrganizations(extracted, fullText, citations, finalLocations))
+	}()
 
-func parseFloat(s string) float64 {
-	var f float64
-	fmt.Sscanf(strings.TrimSpace(s), "%f", &f)
-	return f
+	return nil
 }
 
-func handleNonStream(db *usecase.UseCase, cfg config.Config, redis redis.Client, conn *websocket.Conn, body io.Reader, userQuestion, geminiQuestion, chatRoomId string) error {
-	all, err := io.ReadAll(body)
-	if err != nil {
-		return err
-	}
-	var raw map[string]any
-	if err := json.Unmarshal(all, &raw); err != nil {
-		return fmt.Errorf("non-stream parse error: %v; body: %s", err, string(all))
-	}
-
-	choices, _ := raw["choices"].([]any)
-	var text string
-	if len(choices) > 0 {
-		if msg, ok := choices[0].(map[string]any)["message"].(map[string]any); ok {
-			text, _ = msg["content"].(string)
-		}
-	}
-	var citations []string
-	if cits, ok := raw["citations"].([]any); ok {
-		for _, c := range cits {
-			if s, ok := c.(string); ok {
-				citations = append(citations, s)
-			}
-		}
-	}
-	if srs, ok := raw["search_results"].([]any); ok {
-		for _, v := range srs {
-			if m, ok := v.(map[string]any); ok {
-				if url, ok := m["url"].(string); ok {
-					citations = append(citations, url)
-				}
-			}
+// answerOrganizations converts the extracted organizations for the
+// directory. The extraction also returns organizations known from earlier
+// turns, so the citations (and a single resolved pin) of this answer are
+// only attached to the ones the answer talks about.
+func answerOrganizations(extracted []cache.Organization, text string, citations []string, locations []entity.Location) []entity.Organization {
+	lower := strings.ToLower(text)
+
+	var mentioned []int
+	orgs := make([]entity.Organization, 0, len(extracted))
+	for _, o := range extracted {
+		var sources []string
+		if o.Name != "" && strings.Contains(lower, strings.ToLower(o.Name)) {
+			sources = citations
+			mentioned = append(mentioned, len(orgs))
 		}
+		orgs = append(orgs, directory.FromCache(o, sources))
 	}
 
-	_ = conn.WriteJSON(map[string]any{
-		"content": map[string]any{
-			"text":          text,
-			"citations":     citations,
-			"location":      nil,
-			"images_url":    nil,
-			"organizations": nil,
-		},
-	})
-
-	err = conn.WriteJSON(map[string]any{
-		"status": "end",
-	})
-	if err != nil {
-		return err
+	if len(mentioned) == 1 && len(locations) == 1 {
+		orgs[mentioned[0]].Location.Latitude = locations[0].Latitude
+		orgs[mentioned[0]].Location.Longitude = locations[0].Longitude
 	}
 
-	go SaveResponce(db, userQuestion, chatRoomId, text, geminiQuestion, citations, nil, nil, nil)
-	organizationsJson, err := cache.GetChatOrganizations(&redis, context.Background(), "o"+chatRoomId, int64(5))
-	go gemini.OrganizationCreate(cfg, redis, text, organizationsJson, chatRoomId)
-
-	return nil
+	return orgs
 }
 
-func SaveResponce(db *usecase.UseCase, request, chat_room_id, responce, gemini_request string, citation_urls []string, locations []map[string]float64, images_url []string, orgs any) {
+func SaveResponce(db *usecase.UseCase, messageID, request, chat_room_id, responce, gemini_request string, citation_urls []string, locations []entity.Location, images_url []string, orgs any, documents []entity.DocumentCitation, promptVersions map[string]int, interrupted bool) {
 
 	var locStrings []string
 	for _, loc := range locations {
@@ -345,6 +202,11 @@ func SaveResponce(db *usecase.UseCase, request, chat_room_id, responce, gemini_r
 		Location:      locStrings,
 		ImagesURL:     images_url,
 		Organizations: orgs,
+
+		Documents:      documents,
+		PromptVersions: promptVersions,
+		Interrupted:    interrupted,
+		MessageID:      messageID,
 	})
 
 	if err != nil {
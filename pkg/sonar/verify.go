@@ -0,0 +1,139 @@
+package sonar
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"chatbot/internal/entity"
+	"chatbot/internal/usecase"
+	"chatbot/pkg/directory"
+	"chatbot/pkg/lang"
+	"chatbot/pkg/prompt"
+)
+
+// Verifier re-queries Sonar for directory organizations that have not been
+// verified for a while and records the facts that changed.
+type Verifier struct {
+	search    SearchProvider
+	prompts   *prompt.Registry
+	repo      usecase.OrganizationRepoI
+	maxAge    time.Duration
+	batchSize int
+}
+
+// NewVerifier -.
+func NewVerifier(search SearchProvider, prompts *prompt.Registry, repo usecase.OrganizationRepoI, maxAge time.Duration, batchSize int) *Verifier {
+	return &Verifier{
+		search:    search,
+		prompts:   prompts,
+		repo:      repo,
+		maxAge:    maxAge,
+		batchSize: batchSize,
+	}
+}
+
+// Run verifies a batch of stale organizations every interval until ctx is
+// done.
+func (v *Verifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := v.VerifyStale(ctx); err != nil {
+				slog.Error("Organization verification failed", "err", err)
+			}
+		}
+	}
+}
+
+// VerifyStale verifies up to one batch of the least recently checked
+// organizations older than the max age.
+func (v *Verifier) VerifyStale(ctx context.Context) error {
+	orgs, err := v.repo.GetStale(ctx, time.Now().Add(-v.maxAge), v.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range orgs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := v.Verify(ctx, o); err != nil {
+			// One failed search should not hold back the rest of the batch;
+			// the organization stays stale and is retried next time.
+			slog.Warn("Failed to verify organization", "organization_id", o.ID, "name", o.Name, "err", err)
+		}
+	}
+
+	return nil
+}
+
+// Verify searches for o, applies the facts that changed and logs them as
+// revisions. When the search does not find o only the check is recorded:
+// it is not searched again before the max age, nor served as fresh.
+func (v *Verifier) Verify(ctx context.Context, o entity.Organization) error {
+	system, _, err := buildSystemPrompt(v.prompts, lang.En, nil)
+	if err != nil {
+		return err
+	}
+
+	place := "Uzbekistan"
+	if o.City != "" {
+		place = o.City + ", " + place
+	}
+	events, err := v.search.Search(ctx, SearchRequest{
+		System:      system,
+		Query:       fmt.Sprintf("Current official address, phone number, email and website of %s in %s", o.Name, place),
+		ContextSize: "medium",
+		Structured:  true,
+	})
+	if err != nil {
+		return err
+	}
+
+	var found *entity.Organization
+	var best float64
+	var citations []string
+	for ev := range events {
+		switch ev.Type {
+		case EventCitation:
+			citations = append(citations, ev.URL)
+		case EventOrganization:
+			candidate := directory.FromOrgInfo(ev.Organization)
+			if m := directory.Compare(o, candidate); m.Confidence >= directory.CandidateConfidence && m.Confidence > best {
+				best, found = m.Confidence, &candidate
+			}
+		case EventError:
+			return ev.Err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if found == nil {
+		slog.Info("Organization not found on re-verification", "organization_id", o.ID, "name", o.Name)
+		return v.repo.MarkChecked(ctx, o.ID)
+	}
+
+	sourceURL := directory.SourceURL(*found)
+	if sourceURL == "" && len(citations) > 0 {
+		sourceURL = citations[0]
+	}
+
+	updated, revisions := directory.Diff(o, *found, sourceURL, time.Now().UTC())
+	if err := v.repo.Reverify(ctx, &updated, revisions); err != nil {
+		return err
+	}
+
+	if len(revisions) > 0 {
+		slog.Info("Organization changed on re-verification", "organization_id", o.ID, "name", o.Name, "changes", len(revisions))
+	}
+	return nil
+}
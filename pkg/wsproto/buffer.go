@@ -0,0 +1,134 @@
+package wsproto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"chatbot/internal/entity"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotBuffered means the answer is not in the buffer: it expired, or it
+// was streamed in another chat room.
+var ErrNotBuffered = errors.New("answer is not buffered")
+
+// ErrStalled means a buffered answer got no new events for the resume
+// window, e.g. the server generating it went down.
+var ErrStalled = errors.New("buffered answer stalled")
+
+// terminal are the event types an answer ends with.
+var terminal = map[string]bool{
+	entity.WSDone:      true,
+	entity.WSCancelled: true,
+	entity.WSError:     true,
+	entity.WSWarning:   true,
+}
+
+// Buffer keeps the events of recent answers in Redis so a client that
+// reconnects can resume them. Every event is stored in a list per answer
+// and published for the clients following it live.
+type Buffer struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+// NewBuffer -.
+func NewBuffer(rdb *redis.Client, ttl time.Duration) *Buffer {
+	return &Buffer{rdb: rdb, ttl: ttl}
+}
+
+// Append stores an encoded envelope of the answer messageID in room.
+func (b *Buffer) Append(ctx context.Context, room, messageID string, event []byte) error {
+	pipe := b.rdb.TxPipeline()
+	pipe.Set(ctx, roomKey(messageID), room, b.ttl)
+	pipe.RPush(ctx, eventsKey(messageID), event)
+	pipe.Expire(ctx, eventsKey(messageID), b.ttl)
+	pipe.Publish(ctx, channel(messageID), event)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Follow sends the buffered events of messageID after seq, then the new
+// ones as they come, until the answer ends or ctx is done.
+func (b *Buffer) Follow(ctx context.Context, room, messageID string, after int, send func(seq int, event []byte) error) error {
+	owner, err := b.rdb.Get(ctx, roomKey(messageID)).Result()
+	if err == redis.Nil || (err == nil && owner != room) {
+		return ErrNotBuffered
+	}
+	if err != nil {
+		return err
+	}
+
+	// Subscribe before reading the list so no event falls in between;
+	// the ones in both are told apart by seq.
+	sub := b.rdb.Subscribe(ctx, channel(messageID))
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+
+	events, err := b.rdb.LRange(ctx, eventsKey(messageID), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	last := after
+	next := func(event []byte) (bool, error) {
+		var head struct {
+			Type string `json:"type"`
+			Seq  int    `json:"seq"`
+		}
+		if err := json.Unmarshal(event, &head); err != nil {
+			return false, err
+		}
+		if head.Seq > last {
+			last = head.Seq
+			if err := send(head.Seq, event); err != nil {
+				return false, err
+			}
+		}
+		return terminal[head.Type], nil
+	}
+
+	for _, event := range events {
+		if done, err := next([]byte(event)); done || err != nil {
+			return err
+		}
+	}
+
+	stalled := time.NewTimer(b.ttl)
+	defer stalled.Stop()
+
+	live := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stalled.C:
+			return ErrStalled
+		case msg, ok := <-live:
+			if !ok {
+				return ErrStalled
+			}
+			if done, err := next([]byte(msg.Payload)); done || err != nil {
+				return err
+			}
+			stalled.Reset(b.ttl)
+		}
+	}
+}
+
+func roomKey(messageID string) string {
+	return "ws:answer:" + messageID + ":room"
+}
+
+func eventsKey(messageID string) string {
+	return "ws:answer:" + messageID + ":events"
+}
+
+func channel(messageID string) string {
+	return "ws:answer:" + messageID
+}
@@ -0,0 +1,24 @@
+package wsproto
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var openConnections = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "ws_connections_open",
+	Help: "Open chat WebSocket connections.",
+})
+
+var connectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "ws_connections_total",
+	Help: "Chat WebSocket connections by protocol version.",
+}, []string{"version"})
+
+// connectionDuration is labelled by why the connection ended: client,
+// idle, timeout (no pong), too_large or error.
+var connectionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "ws_connection_duration_seconds",
+	Help:    "Chat WebSocket connection lifetimes by close reason.",
+	Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+}, []string{"reason"})
@@ -0,0 +1,365 @@
+package wsproto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"chatbot/config"
+	"chatbot/internal/entity"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Protocol versions. Version1 is the untyped frames clients read before the
+// envelope; it is kept for the clients that do not negotiate a version.
+const (
+	Version1 = 1
+	Version2 = 2
+)
+
+// Subprotocols are the Sec-WebSocket-Protocol values the server accepts.
+// The first one the client offers wins.
+var Subprotocols = []string{"chatbot.v2", "chatbot.v1"}
+
+var versions = map[string]int{
+	"chatbot.v1": Version1,
+	"chatbot.v2": Version2,
+}
+
+// Conn writes events in the protocol version negotiated on the handshake
+// and keeps the connection alive. Dead peers are dropped when a pong is
+// missing, slow ones when a write misses its deadline. It is safe for
+// concurrent use.
+type Conn struct {
+	ws      *websocket.Conn
+	version int
+	cfg     config.WS
+	opened  time.Time
+
+	buffer *Buffer
+	room   string
+
+	mu sync.Mutex
+	// broken is set once a write fails; buffered answers go on without
+	// the socket.
+	broken bool
+
+	idle      *time.Timer
+	done      chan struct{}
+	closeOnce sync.Once
+
+	reasonMu sync.Mutex
+	reason   string
+}
+
+// New starts the heartbeat and the idle timer of ws. Version 2 answers are
+// buffered for room when buffer is not nil. The caller must Close the Conn
+// when done reading.
+func New(ws *websocket.Conn, cfg config.WS, buffer *Buffer, room string) *Conn {
+	version, ok := versions[ws.Subprotocol()]
+	if !ok {
+		version = Version1
+	}
+
+	c := &Conn{
+		ws:      ws,
+		version: version,
+		cfg:     cfg,
+		opened:  time.Now(),
+		buffer:  buffer,
+		room:    room,
+		done:    make(chan struct{}),
+	}
+
+	ws.SetReadLimit(cfg.MaxMessageSize)
+	_ = ws.SetReadDeadline(time.Now().Add(cfg.PongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(cfg.PongWait))
+	})
+	c.idle = time.AfterFunc(cfg.IdleTimeout, c.closeIdle)
+	go c.ping()
+
+	openConnections.Inc()
+	connectionsTotal.WithLabelValues(strconv.Itoa(version)).Inc()
+
+	return c
+}
+
+// Read returns the next client message.
+func (c *Conn) Read() ([]byte, error) {
+	_, msg, err := c.ws.ReadMessage()
+	if err != nil {
+		c.setReason(readReason(err))
+		return nil, err
+	}
+	_ = c.ws.SetReadDeadline(time.Now().Add(c.cfg.PongWait))
+	return msg, nil
+}
+
+// Busy stops the idle timer while an answer is streamed.
+func (c *Conn) Busy() {
+	c.idle.Stop()
+}
+
+// Idle restarts the idle timer of an open connection.
+func (c *Conn) Idle() {
+	select {
+	case <-c.done:
+	default:
+		c.idle.Reset(c.cfg.IdleTimeout)
+	}
+}
+
+// Close stops the heartbeat, closes the connection and records it.
+func (c *Conn) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.idle.Stop()
+		c.ws.Close()
+
+		c.setReason("error")
+		c.reasonMu.Lock()
+		reason := c.reason
+		c.reasonMu.Unlock()
+
+		openConnections.Dec()
+		connectionDuration.WithLabelValues(reason).Observe(time.Since(c.opened).Seconds())
+	})
+}
+
+func (c *Conn) ping() {
+	ticker := time.NewTicker(c.cfg.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			// A failed ping is left to the read deadline.
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.cfg.WriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// closeIdle ends a connection nobody asked anything on for the idle
+// timeout. The read loop sees it as a failed read.
+func (c *Conn) closeIdle() {
+	c.setReason("idle")
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "idle timeout")
+	_ = c.ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(c.cfg.WriteWait))
+	c.ws.Close()
+}
+
+// setReason records why the connection ended; the first reason wins.
+func (c *Conn) setReason(reason string) {
+	c.reasonMu.Lock()
+	defer c.reasonMu.Unlock()
+	if c.reason == "" {
+		c.reason = reason
+	}
+}
+
+func readReason(err error) string {
+	var netErr net.Error
+	switch {
+	case websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return "client"
+	case errors.Is(err, websocket.ErrReadLimit):
+		return "too_large"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	}
+	return "error"
+}
+
+// Version returns the negotiated protocol version.
+func (c *Conn) Version() int {
+	return c.version
+}
+
+// Resumable reports whether answers outlive a dropped connection.
+func (c *Conn) Resumable() bool {
+	return c.version >= Version2 && c.buffer != nil
+}
+
+// Message starts the events of one answer under a new message ID.
+func (c *Conn) Message() *Message {
+	return &Message{conn: c, id: uuid.NewString(), buffered: c.Resumable()}
+}
+
+// Resume sends the events of messageID after seq that the client missed,
+// then the live ones until the answer ends. The returned Message goes on
+// from the last event sent and is not buffered. ErrNotBuffered means the
+// answer has to be sent from storage.
+func (c *Conn) Resume(ctx context.Context, messageID string, after int) (*Message, error) {
+	m := &Message{conn: c, id: messageID, seq: after}
+	if !c.Resumable() {
+		return m, ErrNotBuffered
+	}
+
+	err := c.buffer.Follow(ctx, c.room, messageID, after, func(seq int, event []byte) error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		m.seq = seq
+		return c.writeMessage(event)
+	})
+	return m, err
+}
+
+// Message writes the events of one answer.
+type Message struct {
+	conn     *Conn
+	id       string
+	seq      int
+	buffered bool
+}
+
+// ID -.
+func (m *Message) ID() string {
+	return m.id
+}
+
+// Delta -.
+func (m *Message) Delta(text string) error {
+	return m.write(entity.WSDelta, entity.WSDeltaPayload{Text: text}, map[string]any{
+		"text": text,
+	})
+}
+
+// Content -.
+func (m *Message) Content(content entity.WSContentPayload) error {
+	return m.write(entity.WSContent, content, map[string]any{
+		"content": content,
+	})
+}
+
+// Organization -.
+func (m *Message) Organization(o entity.OrgInfo) error {
+	return m.write(entity.WSOrganization, entity.WSOrganizationPayload{Organization: o}, map[string]any{
+		"type":         "organization",
+		"organization": o,
+	})
+}
+
+// Citation -.
+func (m *Message) Citation(d entity.DocumentCitation) error {
+	return m.write(entity.WSCitation, entity.WSCitationPayload{Citation: d}, map[string]any{
+		"type":     "citation",
+		"citation": d,
+	})
+}
+
+// Location sends a resolved map pin ahead of the content. Version 1 only
+// gets the pins with the content.
+func (m *Message) Location(l entity.Location) error {
+	return m.write(entity.WSLocation, l, nil)
+}
+
+// Warning -.
+func (m *Message) Warning(code, message string) error {
+	return m.write(entity.WSWarning, entity.WSWarningPayload{Code: code, Message: message}, map[string]any{
+		"type":  "warning",
+		"error": message,
+	})
+}
+
+// Error -.
+func (m *Message) Error(code, message string, retryable bool) error {
+	return m.write(entity.WSError, entity.WSErrorPayload{Code: code, Message: message, Retryable: retryable}, map[string]any{
+		"type":      "error",
+		"code":      code,
+		"error":     message,
+		"retryable": retryable,
+	})
+}
+
+// Done -.
+func (m *Message) Done() error {
+	return m.write(entity.WSDone, entity.WSDonePayload{}, map[string]any{
+		"status": "end",
+	})
+}
+
+// Cancelled -.
+func (m *Message) Cancelled() error {
+	return m.write(entity.WSCancelled, entity.WSCancelledPayload{}, map[string]any{
+		"status": "cancelled",
+	})
+}
+
+// write sends payload in an envelope, or legacy as is to version 1 clients.
+// A nil legacy frame is not sent to them.
+func (m *Message) write(typ string, payload any, legacy map[string]any) error {
+	c := m.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.version == Version1 {
+		if legacy == nil {
+			return nil
+		}
+		return c.writeJSON(legacy)
+	}
+
+	m.seq++
+	event, err := json.Marshal(entity.WSEnvelope{
+		Type:      typ,
+		MessageID: m.id,
+		Seq:       m.seq,
+		Payload:   payload,
+	})
+	if err != nil {
+		return err
+	}
+	if !m.buffered {
+		return c.writeMessage(event)
+	}
+
+	// Every write on the connection waits for the buffer, so a hung Redis
+	// gets no longer than a slow client.
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.WriteWait)
+	err = c.buffer.Append(ctx, c.room, m.id, event)
+	cancel()
+	if err != nil {
+		slog.Warn("Failed to buffer answer event", "message_id", m.id, "err", err)
+	}
+	// The client may come back for the answer, so it is finished without
+	// the socket.
+	if c.broken {
+		return nil
+	}
+	if err := c.writeMessage(event); err != nil {
+		c.broken = true
+		slog.Info("Client dropped mid-answer, buffering the rest", "message_id", m.id, "err", err)
+	}
+	return nil
+}
+
+// writeJSON writes v within the write deadline. The caller holds c.mu.
+func (c *Conn) writeJSON(v any) error {
+	if err := c.ws.SetWriteDeadline(time.Now().Add(c.cfg.WriteWait)); err != nil {
+		return err
+	}
+	return c.ws.WriteJSON(v)
+}
+
+// writeMessage writes an encoded event within the write deadline. The
+// caller holds c.mu.
+func (c *Conn) writeMessage(event []byte) error {
+	if err := c.ws.SetWriteDeadline(time.Now().Add(c.cfg.WriteWait)); err != nil {
+		return err
+	}
+	return c.ws.WriteMessage(websocket.TextMessage, event)
+}
@@ -0,0 +1,84 @@
+package wsproto
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"chatbot/config"
+
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+// hungRedis never answers: every command waits for its context.
+type hungRedis struct{}
+
+func (hungRedis) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (hungRedis) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+func (hungRedis) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+func TestWriteHungBuffer(t *testing.T) {
+	cfg := config.WS{
+		PingInterval: time.Minute,
+		PongWait:     time.Minute,
+		WriteWait:    50 * time.Millisecond,
+		IdleTimeout:  time.Minute,
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	defer rdb.Close()
+	rdb.AddHook(hungRedis{})
+
+	written := make(chan error, 1)
+	upgrader := websocket.Upgrader{Subprotocols: Subprotocols}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		c := New(ws, cfg, NewBuffer(rdb, time.Minute), "room")
+		defer c.Close()
+
+		written <- c.Message().Delta("Salom")
+		_, _ = c.Read()
+	}))
+	defer srv.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{"chatbot.v2"}}
+	ws, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	select {
+	case err := <-written:
+		if err != nil {
+			t.Fatalf("Delta() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("write is stuck on the buffer")
+	}
+
+	// The event still reaches the client.
+	_ = ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, data, err := ws.ReadMessage(); err != nil || !strings.Contains(string(data), "Salom") {
+		t.Errorf("read %q, %v, want the delta", data, err)
+	}
+}